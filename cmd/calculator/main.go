@@ -23,9 +23,14 @@ import (
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/factories"
 	"github.com/project-alvarium/scoring-apps-go/internal/bootstrap"
 	"github.com/project-alvarium/scoring-apps-go/internal/calculator"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/decay"
 	"github.com/project-alvarium/scoring-apps-go/internal/calculator/policy"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/propagation"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/scoring"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/store"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
 	"github.com/project-alvarium/scoring-apps-go/internal/config"
-	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+	"github.com/project-alvarium/scoring-apps-go/internal/deadletter"
 	"os"
 )
 
@@ -44,6 +49,33 @@ func main() {
 		"./res/config.json",
 		"Path to JSON configuration file.")
 
+	// Backfill unscored data
+	var backfill bool
+	flag.BoolVar(&backfill,
+		"backfill",
+		false,
+		"Queue all data without a current score for scoring on startup.")
+
+	var from, to, host string
+	flag.StringVar(&from,
+		"from",
+		"",
+		"Only backfill data created at or after this RFC3339 timestamp.")
+	flag.StringVar(&to,
+		"to",
+		"",
+		"Only backfill data created at or before this RFC3339 timestamp.")
+	flag.StringVar(&host,
+		"host",
+		"",
+		"Only backfill data annotated on this host.")
+
+	var rate int
+	flag.IntVar(&rate,
+		"rate",
+		10,
+		"The number of data items queued per second during a backfill.")
+
 	flag.Parse()
 
 	fileFormat := config.GetFileExtension(configPath)
@@ -66,38 +98,136 @@ func main() {
 	logger.Write(slog.LevelDebug, "config loaded successfully")
 	logger.Write(slog.LevelDebug, cfg.AsString())
 
-	chKeys := make(chan string)
-	sub, err := calculator.NewSubscriber(cfg.Stream.Subscribe, chKeys, logger)
+	deadLetters, err := deadletter.NewDeadLetters(cfg.DeadLetter, cfg.Database, deadletter.SourceCalculator, logger)
 	if err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
 	}
 
-	chScore := make(chan string)
-	coll := calculator.NewCollector(chKeys, chScore, logger)
+	chKeys := make(chan string)
+	sub, err := calculator.NewSubscriber(cfg.Stream.Subscribe, chKeys, deadLetters, logger)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
 
-	p := policies.DcfPolicy{}
-	p.Name = mode
 	provider, err := policy.NewPolicyProvider(cfg.Policy, logger)
 	if err != nil {
 		logger.Error(err.Error())
 		return
 	}
-	weights, err := provider.GetWeights(p.Name)
+
+	// A score is calculated under every configured classifier, or just the mode if none are configured
+	classifiers := cfg.Classifiers
+	if len(classifiers) == 0 {
+		classifiers = []string{mode}
+	}
+	dcfPolicies, err := calculator.LoadPolicies(provider, classifiers)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	policySet := types.NewPolicySet(dcfPolicies)
+
+	var decider policy.DecisionProvider
+	if cfg.Policy.Decides() {
+		decider, err = policy.NewDecisionProvider(cfg.Policy)
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+		logger.Write(slog.LevelDebug, "scores will be decided by the policy")
+	}
+
+	strategy, err := scoring.NewScoringStrategy(cfg.Scoring)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	logger.Write(slog.LevelDebug, "scoring strategy "+string(strategy.Name()))
+	decayFn, err := decay.NewDecayFunction(cfg.Decay)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	propagationFn, err := propagation.NewPropagationFunction(cfg.Propagation)
 	if err != nil {
 		logger.Error(err.Error())
 		return
 	}
-	p.Weights = weights
-	calc := calculator.NewCalculator(chScore, cfg.Database, logger, p)
+	keyStore, err := store.NewKeyStore(cfg.Queue)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	defer keyStore.Close()
+
+	layers, err := types.NewLayerGraph(cfg.Stack)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	// Persisted scores are announced only if a publisher stream is configured
+	var pub *calculator.Publisher
+	if cfg.Stream.Publish.Type != "" {
+		pub, err = calculator.NewPublisher(cfg.Stream.Publish, logger)
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+	}
+
+	expectations := types.NewExpectations(cfg.Collector, dcfPolicies)
+	chScore := make(chan string)
+	coll := calculator.NewCollector(chKeys, chScore, cfg.Database, cfg.Collector, expectations, keyStore, logger)
+	calc := calculator.NewCalculator(chScore, cfg.Database, calculator.CalculatorOptions{
+		Policies:     policySet,
+		Strategy:     strategy,
+		Decay:        decayFn,
+		Propagation:  propagationFn,
+		Decider:      decider,
+		Expectations: expectations,
+		Layers:       layers,
+		Missing:      cfg.Missing,
+		KeyStore:     keyStore,
+		Collector:    &coll,
+		Publisher:    pub,
+		Workers:      cfg.Workers,
+		Cascade:      cfg.Cascade,
+	}, logger)
+	var handlers []bootstrap.BootstrapHandler
+	if deadLetters != nil {
+		// The collection has to exist before the first message can be dead-lettered
+		handlers = append(handlers, deadLetters.BootstrapHandler)
+	}
+	handlers = append(handlers, sub.BootstrapHandler, coll.BootstrapHandler, calc.BootstrapHandler)
+	if pub != nil {
+		handlers = append(handlers, pub.BootstrapHandler)
+	}
+
+	reloader, err := calculator.NewReloader(cfg.Reload, configPath, cfg.Policy.Type, provider, classifiers, policySet,
+		expectations, logger)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	handlers = append(handlers, reloader.BootstrapHandler)
+
+	if backfill {
+		filter, err := types.NewBackfillFilter(from, to, host)
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+		bf := calculator.NewBackfill(&coll, cfg.Database, filter, policySet, rate, logger)
+		handlers = append(handlers, bf.BootstrapHandler)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	bootstrap.Run(
 		ctx,
 		cancel,
 		cfg,
-		[]bootstrap.BootstrapHandler{
-			sub.BootstrapHandler,
-			coll.BootstrapHandler,
-			calc.BootstrapHandler,
-		})
+		handlers)
 }
@@ -0,0 +1,135 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	sdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/factories"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/deadletter"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// view shows the payload of a dead letter as text rather than base64
+type view struct {
+	documents.DeadLetter
+	Payload string `json:"payload"`
+}
+
+func main() {
+	// Load config
+	var configPath string
+	flag.StringVar(&configPath,
+		"cfg",
+		"./res/config.json",
+		"Path to JSON configuration file.")
+
+	var pending bool
+	flag.BoolVar(&pending,
+		"pending",
+		false,
+		"Only list or replay the dead letters that have not been replayed yet.")
+
+	var key string
+	flag.StringVar(&key,
+		"replay",
+		"",
+		"Replay the dead letter with this key.")
+
+	var all bool
+	flag.BoolVar(&all,
+		"all",
+		false,
+		"Replay every listed dead letter. Combine with -pending to skip the ones already replayed.")
+	flag.Parse()
+
+	fileFormat := config.GetFileExtension(configPath)
+	reader, err := config.NewReader(fileFormat)
+	if err != nil {
+		tmpLog := factories.NewLogger(sdkConfig.LoggingInfo{MinLogLevel: slog.LevelError})
+		tmpLog.Error(err.Error())
+		os.Exit(1)
+	}
+
+	cfg := deadletter.ApplicationConfig{}
+	err = reader.Read(configPath, &cfg)
+	if err != nil {
+		tmpLog := factories.NewLogger(sdkConfig.LoggingInfo{MinLogLevel: slog.LevelError})
+		tmpLog.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger := factories.NewLogger(cfg.Logging)
+	logger.Write(slog.LevelDebug, "config loaded successfully")
+
+	ctx := context.Background()
+	store, err := deadletter.NewStore(cfg.Database)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	var letters []documents.DeadLetter
+	if key != "" {
+		letter, err := store.Get(ctx, key)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		letters = append(letters, letter)
+	} else {
+		letters, err = store.List(ctx, pending)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if key == "" && !all {
+		for _, letter := range letters {
+			b, _ := json.Marshal(view{DeadLetter: letter, Payload: string(letter.Payload)})
+			fmt.Println(string(b))
+		}
+		return
+	}
+
+	replayer, err := deadletter.NewReplayer(cfg.Replay, store)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, letter := range letters {
+		err = replayer.Replay(ctx, letter)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to replay %s: %s", letter.Key.String(), err.Error()))
+			failed = true
+			continue
+		}
+		logger.Write(slog.LevelInfo, "replayed "+letter.Key.String())
+	}
+	replayer.Close()
+	if failed {
+		os.Exit(1)
+	}
+}
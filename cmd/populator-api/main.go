@@ -70,8 +70,14 @@ func main() {
 		os.Exit(-1)
 	}
 
+	simulator, err := populator_api.NewSimulator(cfg.Simulate, dbArango, logger)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(-1)
+	}
+
 	r := mux.NewRouter()
-	populator_api.LoadRestRoutes(r, dbArango, dbMongo, logger)
+	populator_api.LoadRestRoutes(r, dbArango, dbMongo, simulator, logger)
 	ctx, cancel := context.WithCancel(context.Background())
 	bootstrap.Run(
 		ctx,
@@ -69,7 +69,7 @@ func main() {
 		os.Exit(-1)
 	}
 
-	worker := populator.NewWorker(dbArango, dbMongo, logger)
+	worker := populator.NewWorker(dbArango, dbMongo, cfg.Policy, logger)
 	ctx, cancel := context.WithCancel(context.Background())
 	bootstrap.Run(
 		ctx,
@@ -24,6 +24,7 @@ import (
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/factories"
 	"github.com/project-alvarium/scoring-apps-go/internal/bootstrap"
 	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/deadletter"
 	"github.com/project-alvarium/scoring-apps-go/internal/subscriber"
 	"github.com/project-alvarium/scoring-apps-go/internal/subscriber/streams"
 	"os"
@@ -58,11 +59,23 @@ func main() {
 	logger.Write(slog.LevelDebug, "config loaded successfully")
 	logger.Write(slog.LevelDebug, cfg.AsString())
 
+	deadLetters, err := deadletter.NewDeadLetters(cfg.DeadLetter, cfg.Database, deadletter.SourceSubscriber, logger)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
 	chMessages := make(chan message.SubscribeWrapper)
-	sub, err := streams.NewSubscriber(cfg.Sdk.Stream, chMessages, cfg.Key, logger)
+	sub, err := streams.NewSubscriber(cfg.Sdk.Stream, chMessages, cfg.Key, deadLetters, logger)
+
+	verifier, err := subscriber.NewVerifier(cfg.Verification)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
 
 	chKeys := make(chan string)
-	graph, err := subscriber.NewArangoClient(chMessages, chKeys, cfg.Database, logger)
+	graph, err := subscriber.NewArangoClient(chMessages, chKeys, cfg.Database, verifier, deadLetters, cfg.Unrecognized, logger)
 	if err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
@@ -74,15 +87,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	handlers := []bootstrap.BootstrapHandler{
+		sub.Subscribe,
+		graph.BootstrapHandler,
+	}
+	if deadLetters != nil {
+		// The graph handler creates the database the dead letters are kept in
+		handlers = append(handlers, deadLetters.BootstrapHandler)
+	}
+	handlers = append(handlers, pub.BootstrapHandler)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	bootstrap.Run(
 		ctx,
 		cancel,
 		cfg,
-		[]bootstrap.BootstrapHandler{
-			sub.Subscribe,
-			graph.BootstrapHandler,
-			pub.BootstrapHandler,
-		})
+		handlers)
 	logger.Write(slog.LevelInfo, "exiting...")
 }
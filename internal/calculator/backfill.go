@@ -0,0 +1,118 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+const (
+	defaultBackfillRate int = 10  // Keys queued per second
+	progressInterval    int = 100 // Keys queued between progress reports
+)
+
+// Backfill finds data that was never scored, for instance because the calculator was down or a message was lost, and
+// queues it with the collector so it goes through the normal scoring path. Data that was scored under some of the
+// policies but not others, such as a policy added since, is picked up as well.
+type Backfill struct {
+	collector *Collector
+	dbConfig  config.DatabaseInfo
+	filter    types.BackfillFilter
+	logger    interfaces.Logger
+	policies  *types.PolicySet
+	rate      int
+}
+
+func NewBackfill(
+	collector *Collector,
+	dbConfig config.DatabaseInfo,
+	filter types.BackfillFilter,
+	dcfPolicies *types.PolicySet,
+	rate int,
+	logger interfaces.Logger,
+) Backfill {
+	if rate <= 0 {
+		rate = defaultBackfillRate
+	}
+	return Backfill{
+		collector: collector,
+		dbConfig:  dbConfig,
+		filter:    filter,
+		logger:    logger,
+		policies:  dcfPolicies,
+		rate:      rate,
+	}
+}
+
+func (b *Backfill) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bool {
+	db, err := NewArangoClient(b.dbConfig, b.logger)
+	if err != nil {
+		b.logger.Error(err.Error())
+		return false
+	}
+
+	return b.start(ctx, wg, db)
+}
+
+// start queues the unscored data found in the database with the collector at the configured rate.
+func (b *Backfill) start(ctx context.Context, wg *sync.WaitGroup, db GraphClient) bool {
+	var names []string
+	for _, p := range b.policies.Load() {
+		names = append(names, p.Name)
+	}
+	keys, err := db.QueryUnscoredData(ctx, b.filter, names)
+	if err != nil {
+		b.logger.Error(err.Error())
+		return false
+	}
+	b.logger.Write(slog.LevelInfo, fmt.Sprintf("backfill found %v unscored data items", len(keys)))
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(time.Second / time.Duration(b.rate))
+		defer ticker.Stop()
+
+		start := time.Now()
+		for i, key := range keys {
+			select {
+			case <-ctx.Done():
+				b.logger.Write(slog.LevelInfo, fmt.Sprintf("backfill stopped after queueing %v of %v", i, len(keys)))
+				return
+			case <-ticker.C:
+				b.collector.Enqueue(key)
+			}
+
+			queued := i + 1
+			if queued%progressInterval == 0 && queued < len(keys) {
+				remaining := time.Duration(len(keys)-queued) * time.Second / time.Duration(b.rate)
+				b.logger.Write(slog.LevelInfo, fmt.Sprintf("backfill queued %v of %v (%.0f%%), about %v remaining",
+					queued, len(keys), float64(queued)*100/float64(len(keys)), remaining.Round(time.Second)))
+			}
+		}
+		b.logger.Write(slog.LevelInfo, fmt.Sprintf("backfill queued %v data items in %v", len(keys),
+			time.Since(start).Round(time.Second)))
+	}()
+	return true
+}
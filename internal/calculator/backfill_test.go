@@ -0,0 +1,139 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+var bindParameter = regexp.MustCompile(`@@?\w+`)
+
+// checkBindVars fails the test unless the query uses exactly the supplied bind variables.
+func checkBindVars(t *testing.T, query string, bindVars map[string]interface{}) {
+	t.Helper()
+	used := make(map[string]bool)
+	for _, p := range bindParameter.FindAllString(query, -1) {
+		name := strings.TrimPrefix(p, "@")
+		used[name] = true
+		if _, ok := bindVars[name]; !ok {
+			t.Errorf("query uses %s, which is not bound", p)
+		}
+	}
+	for name := range bindVars {
+		if !used[name] {
+			t.Errorf("%s is bound but not used by the query", name)
+		}
+	}
+}
+
+func TestUnscoredDataQuery(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		filter   types.BackfillFilter
+		expected []string
+	}{
+		{"unfiltered", types.BackfillFilter{}, []string{"@scoring", "policies"}},
+		{"range", types.BackfillFilter{From: from, To: to}, []string{"@scoring", "from", "policies", "to"}},
+		{"host", types.BackfillFilter{Host: "host-a"}, []string{"@scoring", "host", "policies"}},
+		{"all", types.BackfillFilter{From: from, To: to, Host: "host-a"}, []string{"@scoring", "from", "host", "policies", "to"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, bindVars := unscoredDataQuery(tt.filter, []string{"default"})
+			checkBindVars(t, query, bindVars)
+
+			var names []string
+			for name := range bindVars {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("expected bind variables %v, received %v", tt.expected, names)
+			}
+			// The host is joined through the annotations made on it, not looked up for every data vertex
+			if strings.Contains(query, "a.dataRef == d._key") {
+				t.Error("expected no correlated annotation subquery")
+			}
+		})
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	now := time.Now()
+	graph := newFakeGraph()
+	graph.data["old"] = now.Add(-2 * time.Hour)
+	graph.data["recent"] = now.Add(-time.Minute)
+	graph.data["scored"] = now.Add(-time.Hour)
+	graph.data["elsewhere"] = now.Add(-time.Minute)
+	graph.annotations["old"] = []documents.Annotation{{DataRef: "old", Host: "host-a"}}
+	graph.annotations["recent"] = []documents.Annotation{{DataRef: "recent", Host: "host-a"}}
+	graph.annotations["scored"] = []documents.Annotation{{DataRef: "scored", Host: "host-a"}}
+	graph.annotations["elsewhere"] = []documents.Annotation{{DataRef: "elsewhere", Host: "host-b"}}
+	graph.addScore("scored", contracts.Application, "default", 1)
+
+	tests := []struct {
+		name     string
+		filter   types.BackfillFilter
+		policies []string
+		expected []string
+	}{
+		{"all", types.BackfillFilter{}, []string{"default"}, []string{"elsewhere", "old", "recent"}},
+		{"host", types.BackfillFilter{Host: "host-a"}, []string{"default"}, []string{"old", "recent"}},
+		{"from", types.BackfillFilter{From: now.Add(-time.Hour)}, []string{"default"}, []string{"elsewhere", "recent"}},
+		{"to", types.BackfillFilter{To: now.Add(-time.Hour)}, []string{"default"}, []string{"old"}},
+		// Data scored before the strict policy was added is backfilled for it
+		{"added policy", types.BackfillFilter{}, []string{"default", "strict"},
+			[]string{"elsewhere", "old", "recent", "scored"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCollector(graph)
+			var dcfPolicies []policies.DcfPolicy
+			for _, name := range tt.policies {
+				dcfPolicies = append(dcfPolicies, policies.DcfPolicy{Name: name})
+			}
+			b := NewBackfill(c, config.DatabaseInfo{}, tt.filter, types.NewPolicySet(dcfPolicies), 1000, newTestLogger())
+
+			var wg sync.WaitGroup
+			if !b.start(context.Background(), &wg, graph) {
+				t.Fatal("expected the backfill to start")
+			}
+			wg.Wait()
+
+			var received []string
+			for _, s := range c.keyMap.Sightings() {
+				received = append(received, s.Key)
+			}
+			slices.Sort(received)
+			if !slices.Equal(received, tt.expected) {
+				t.Errorf("expected %v to be queued, received %v", tt.expected, received)
+			}
+		})
+	}
+}
@@ -18,40 +18,106 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-
+	"math"
+	"slices"
+	"strings"
 	"sync"
-	"time"
 
+	"github.com/oklog/ulid/v2"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/decay"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/policy"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/propagation"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/scoring"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/store"
 	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
 	"github.com/project-alvarium/scoring-apps-go/internal/config"
 	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
 	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+	"github.com/project-alvarium/scoring-apps-go/pkg/requests"
 )
 
 type Calculator struct {
-	chKeys    chan string
-	condition *sync.Cond
-	dbClient  *ArangoClient
-	dbConfig  config.DatabaseInfo
-	logger    interfaces.Logger
-	workQueue *types.WorkQueue
-	policy    policies.DcfPolicy
+	cascade      config.CascadeInfo
+	cascadePaths *types.CascadePaths
+	chKeys       chan string
+	collector    *Collector
+	dbClient     GraphClient
+	dbConfig     config.DatabaseInfo
+	decay        decay.DecayFunction
+	decider      policy.DecisionProvider
+	deferred     *types.DeferredKeys
+	expectations *types.Expectations
+	keyStore     store.KeyStore
+	layers       *types.LayerGraph
+	logger       interfaces.Logger
+	missing      map[contracts.LayerType]config.MissingInfo
+	policies     *types.PolicySet
+	propagation  propagation.PropagationFunction
+	publisher    *Publisher // publisher announces every persisted score, if a publisher stream is configured
+	stored       *sync.Map  // stored holds the key of the version last stored for each policy name
+	strategy     scoring.ScoringStrategy
+	workers      int
 }
 
 const (
-	workerMax int = 5
+	defaultWorkers int = 5
+	defaultFanOut  int = 100
 )
 
-func NewCalculator(chKeys chan string, dbConfig config.DatabaseInfo, logger interfaces.Logger, policy policies.DcfPolicy) Calculator {
+// decidedStrategy is recorded as the strategy of scores whose confidence was decided by the policy.
+const decidedStrategy string = "opa-decision"
+
+// CalculatorOptions holds the functions, settings and collaborators a Calculator scores keys with.
+type CalculatorOptions struct {
+	Policies     *types.PolicySet
+	Strategy     scoring.ScoringStrategy
+	Decay        decay.DecayFunction
+	Propagation  propagation.PropagationFunction
+	Decider      policy.DecisionProvider // Decider decides confidences with the policy, if set
+	Expectations *types.Expectations
+	Layers       *types.LayerGraph
+	Missing      map[contracts.LayerType]config.MissingInfo
+	KeyStore     store.KeyStore
+	Collector    *Collector
+	Publisher    *Publisher // Publisher announces every persisted score, if set
+	Workers      int        // Workers is the number of keys scored at once, 5 by default
+	Cascade      config.CascadeInfo
+}
+
+func NewCalculator(
+	chKeys chan string,
+	dbConfig config.DatabaseInfo,
+	opts CalculatorOptions,
+	logger interfaces.Logger,
+) Calculator {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.Cascade.FanOut <= 0 {
+		opts.Cascade.FanOut = defaultFanOut
+	}
 	return Calculator{
-		chKeys:    chKeys,
-		condition: sync.NewCond(&sync.Mutex{}),
-		dbConfig:  dbConfig,
-		logger:    logger,
-		workQueue: types.NewWorkQueue(),
-		policy:    policy,
+		cascade:      opts.Cascade,
+		cascadePaths: types.NewCascadePaths(),
+		chKeys:       chKeys,
+		collector:    opts.Collector,
+		dbConfig:     dbConfig,
+		decay:        opts.Decay,
+		decider:      opts.Decider,
+		deferred:     types.NewDeferredKeys(),
+		expectations: opts.Expectations,
+		keyStore:     opts.KeyStore,
+		layers:       opts.Layers,
+		logger:       logger,
+		missing:      opts.Missing,
+		policies:     opts.Policies,
+		propagation:  opts.Propagation,
+		publisher:    opts.Publisher,
+		stored:       &sync.Map{},
+		strategy:     opts.Strategy,
+		workers:      opts.Workers,
 	}
 }
 
@@ -68,179 +134,349 @@ func (c *Calculator) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) b
 		return false
 	}
 
-	c.dbClient = db
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-
-		for {
-			// incoming keys should trigger calculation for associated data
-			msg, ok := <-c.chKeys
-			c.workQueue.Append(msg)
-			if !ok {
-				return
-			}
-		}
-	}()
-
-	cancelled := false
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for !cancelled {
-			if c.workQueue.Len() > 0 {
-				c.condition.L.Lock()
-				if c.workQueue.Workers.Count() >= workerMax {
-					c.condition.Wait()
-				}
+	err = db.EnsureIndexes(ctx)
+	if err != nil {
+		c.logger.Error(err.Error())
+		return false
+	}
 
-				c.condition.L.Unlock()
-				key := c.workQueue.First()
-				c.logger.Write(slog.LevelDebug, fmt.Sprintf("workers %v len %v scored %s", c.workQueue.Workers.Count(), c.workQueue.Len(), key))
-				go c.score(ctx, key)
-			} else {
-				time.Sleep(250 * time.Millisecond)
-			}
-		}
-	}()
+	c.dbClient = db
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go c.work(ctx, wg, i)
+	}
 
 	wg.Add(1)
 	go func() { // Graceful shutdown
 		defer wg.Done()
 
 		<-ctx.Done()
-		cancelled = true
 		c.logger.Write(slog.LevelInfo, "shutdown received")
 	}()
 	return true
 }
 
-func (c *Calculator) score(ctx context.Context, key string) {
-	c.workQueue.Workers.Increment()
-	defer c.workQueue.Workers.Decrement()
+// work scores incoming keys until the context is cancelled or the key channel is closed. A key that is already being
+// scored when the context is cancelled is allowed to finish. Keys that were not picked up remain in the key store and
+// are recovered on the next start.
+func (c *Calculator) work(ctx context.Context, wg *sync.WaitGroup, id int) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case key, ok := <-c.chKeys:
+			if !ok {
+				return
+			}
+			c.logger.Write(slog.LevelDebug, fmt.Sprintf("worker %v scoring %s", id, key))
+			c.score(context.WithoutCancel(ctx), key)
+		}
+	}
+}
 
-	time.Sleep(1500 * time.Millisecond)
+func (c *Calculator) score(ctx context.Context, key string) {
+	path := c.cascadePaths.Take(key)
 	annotations, err := c.dbClient.QueryAnnotations(ctx, key)
 	if err != nil {
 		c.logger.Error(err.Error())
 		return
 	}
-	var layer contracts.LayerType = annotations[0].Layer
-	var docScore documents.Score
-
-	tagFieldScores := make(map[string]documents.Score)  // Scores of the "tag" fields of the received annotations
-	hostFieldScores := make(map[string]documents.Score) // Scores of the "host" fields of the received annotations
-	switch layer {
-	case contracts.Application:
-		// Find the confidence scores of:
-		// 1. CICD pipelines that built the apps that processed the piece of data
-		// 2. OS on which the app is running
-		for _, annotation := range annotations {
-			// Check if the confidence for the tag field is already computed
-			if _, exists := tagFieldScores[annotation.Tag]; !exists {
-				tagScore, err := c.dbClient.QueryScoreByTag(ctx, annotation.Tag, contracts.CiCd)
-				if err != nil {
-					c.logger.Error(err.Error())
-				} else {
-					tagFieldScores[annotation.Tag] = tagScore
-				}
-			}
+	if len(annotations) == 0 {
+		c.logger.Write(slog.LevelDebug, "no annotations found for "+key)
+		c.release(key)
+		return
+	}
 
-			// Check if the confidence for the host field is already computed
-			if _, exists := hostFieldScores[annotation.Host]; !exists {
-				hostFieldScore, err := c.dbClient.QueryScoreByTag(ctx, annotation.Host, contracts.Os)
-				if err != nil {
-					c.logger.Error(err.Error())
-				} else {
-					hostFieldScores[annotation.Host] = hostFieldScore
-				}
-			}
+	// Find the lower layer scores for every policy before persisting anything, so that a key deferred under one
+	// policy is not scored under the others in the meantime. The policies are loaded once so a reload of the weights
+	// part way through does not mix old and new ones.
+	dcfPolicies := c.policies.Load()
+	dependencies := make([][]scoring.Dependency, len(dcfPolicies))
+	missing := make([][]documents.MissingScore, len(dcfPolicies))
+	deferred := false
+	for i, p := range dcfPolicies {
+		// A lower layer score that cannot be looked up aborts the scoring, leaving the key in the store for a retry
+		dependencies[i], missing[i], err = scoring.LowerLayers(ctx, c.dbClient, c.layers, c.missing, annotations, p.Name)
+		if err != nil {
+			c.logger.Error(err.Error())
+			return
 		}
+		deferred = c.deferMissing(key, missing[i]) || deferred
+	}
+	if deferred {
+		return
+	}
 
-		// Calculate the app layer confidence, now influenced by the CICD scores and OS scores
-		docScore = documents.NewScore(key, annotations, c.policy, tagFieldScores, hostFieldScores)
-		err = c.dbClient.CreateDocument(ctx, docScore.Key.String(), docScore, documents.VertexScores)
+	for i, p := range dcfPolicies {
+		// Let the policy decide the weights or the confidence in the context of the annotations, if it can
+		decision, err := c.decide(ctx, key, annotations, p, dependencies[i])
 		if err != nil {
 			c.logger.Error(err.Error())
 			return
 		}
-
-		// Create an edge between the score and the data
-		err = c.dbClient.CreateEdge(ctx, docScore.Key.String(), key, documents.EdgeScoring)
+		// Only the configured policy is stored as a version. Weights decided for this data are kept on the explanation.
+		policyKey, err := c.storePolicy(ctx, p)
+		if err != nil {
+			c.logger.Error(err.Error())
+			return
+		}
+		if len(decision.Weights) > 0 {
+			p.Weights = decision.Weights
+		}
+		// Calculate the layer confidence, influenced by the lower layer scores
+		docScore := c.newScore(key, annotations, p, dependencies[i], missing[i])
+		docScore.Explanation.PolicyKey = policyKey
+		docScore.Explanation.DecidedWeights = decision.Weights
+		if decision.Confidence != nil {
+			docScore.Confidence = math.Round(*decision.Confidence*100) / 100
+			docScore.Band = p.Band(docScore.Confidence)
+			docScore.Strategy = decidedStrategy
+			docScore.Explanation.Strategy = decidedStrategy
+		}
+		// Fold in the confidence of the data this data was mutated from, if any
+		docScore, err = c.propagate(ctx, docScore, p)
+		if err != nil {
+			c.logger.Error(err.Error())
+			return
+		}
+		// Persist the score as the current version for the policy, linked to the data and the policy version
+		docScore, err = c.dbClient.CreateScore(ctx, docScore, policyKey)
 		if err != nil {
 			c.logger.Error(err.Error())
 			return
 		}
 
-		for _, tagScore := range tagFieldScores {
-			// Create an edge between the app score and CICD score
-			err = c.dbClient.CreateEdge(ctx, tagScore.Key.String(), docScore.Key.String(), documents.EdgeStack)
-			if err != nil {
-				c.logger.Error(err.Error())
-				return
+		for _, dependency := range dependencies[i] {
+			for _, fieldScore := range dependency.Scores {
+				// Create an edge between the lower layer score and this score
+				err = c.createStackEdge(ctx, fieldScore, docScore)
+				if err != nil {
+					c.logger.Error(err.Error())
+					return
+				}
 			}
 		}
 
-		for _, hostFieldScore := range hostFieldScores {
-			// Create an edge between the app score and OS score
-			err = c.dbClient.CreateEdge(ctx, hostFieldScore.Key.String(), docScore.Key.String(), documents.EdgeStack)
+		if c.publisher != nil {
+			// The score is already persisted, so a failure to announce it does not stop the dependents being scored
+			err = c.publisher.Publish(ctx, docScore)
 			if err != nil {
 				c.logger.Error(err.Error())
-				return
 			}
 		}
 
-	case contracts.Os:
-		// Find the confidence scores of host on which the OS is running
-		for _, annotation := range annotations {
-			// Check if the confidence for the tag is already computed
-			if _, exists := tagFieldScores[annotation.Tag]; !exists {
-				tagScore, err := c.dbClient.QueryScoreByTag(ctx, annotation.Tag, contracts.Host)
-				if err != nil {
-					c.logger.Error(err.Error())
-					return
-				}
-				tagFieldScores[annotation.Tag] = tagScore
+		c.resumeDeferred(docScore)
+		c.rescoreDependents(ctx, docScore, path)
+	}
+	c.release(key)
+}
+
+// deferMissing sets the key aside until the lower layer is scored for the field value of every missing score with the
+// defer penalty. It returns true if there were any.
+func (c *Calculator) deferMissing(key string, missing []documents.MissingScore) bool {
+	deferred := false
+	for _, m := range missing {
+		if m.Penalty != string(config.DeferMissing) {
+			continue
+		}
+		c.logger.Write(slog.LevelDebug, fmt.Sprintf("deferring %s until %s is scored for %s", key, m.Layer, m.Field))
+		c.deferred.Add(m.Layer, m.Field, key)
+		deferred = true
+	}
+	return deferred
+}
+
+// resumeDeferred queues the keys that were waiting for the layer of the new score to be scored for any of its tags.
+func (c *Calculator) resumeDeferred(score documents.Score) {
+	for _, key := range c.deferred.Take(score.Layer, score.Tag) {
+		c.logger.Write(slog.LevelDebug, fmt.Sprintf("resuming %s after new %s score for %v", key, score.Layer, score.Tag))
+		c.collector.Enqueue(key)
+	}
+}
+
+// decide asks the decision provider, if there is one, how to score the annotations under the policy. The lower layer
+// scores are passed as found, before any decay.
+func (c *Calculator) decide(
+	ctx context.Context,
+	key string,
+	annotations []documents.Annotation,
+	p policies.DcfPolicy,
+	dependencies []scoring.Dependency,
+) (policy.Decision, error) {
+	if c.decider == nil {
+		return policy.Decision{}, nil
+	}
+
+	input := requests.OpaDecisionInput{
+		Classifier:  p.Name,
+		DataRef:     key,
+		Layer:       annotations[0].Layer,
+		Annotations: annotations,
+	}
+	for _, a := range annotations {
+		if !slices.Contains(input.Hosts, a.Host) {
+			input.Hosts = append(input.Hosts, a.Host)
+		}
+		if !slices.Contains(input.Tags, a.Tag) {
+			input.Tags = append(input.Tags, a.Tag)
+		}
+	}
+	for _, d := range dependencies {
+		for field, s := range d.Scores {
+			l := requests.OpaLowerLayerScore{
+				Layer:      d.Layer,
+				JoinOn:     string(d.JoinOn),
+				Field:      field,
+				Confidence: s.Confidence,
+				Timestamp:  s.Timestamp,
+			}
+			if s.Key != (ulid.ULID{}) {
+				l.ScoreKey = s.Key.String()
 			}
+			input.LowerLayers = append(input.LowerLayers, l)
 		}
+	}
+	// Sort the lower layers so the same context always makes the same request and can be answered from the cache
+	slices.SortFunc(input.LowerLayers, func(a, b requests.OpaLowerLayerScore) int {
+		if a.Layer != b.Layer {
+			return strings.Compare(string(a.Layer), string(b.Layer))
+		}
+		return strings.Compare(a.Field, b.Field)
+	})
+	return c.decider.Decide(ctx, input)
+}
 
-		// Calculate the OS layer confidence, now influenced by the host scores
-		docScore = documents.NewScore(key, annotations, c.policy, tagFieldScores, hostFieldScores)
-		err = c.dbClient.CreateDocument(ctx, docScore.Key.String(), docScore, documents.VertexScores)
-		if err != nil {
-			c.logger.Error(err.Error())
-			return
+// propagate folds the current confidence of the parents the data was mutated from, under the same policy and layer,
+// into the score with the configured propagation function. The parent scores used are recorded on the score. Parents
+// that have not been scored, such as data from unannotated sources, are left out of the combination and only recorded
+// as missing, so that they do not pin the confidence of the data to zero. Their data is scored again once they are.
+func (c *Calculator) propagate(ctx context.Context, docScore documents.Score, p policies.DcfPolicy) (documents.Score, error) {
+	if c.propagation.Name() == config.NoPropagation {
+		return docScore, nil
+	}
+	parents, err := c.dbClient.QueryParentScores(ctx, docScore.DataRef, docScore.Layer, p.Name)
+	if err != nil {
+		return docScore, err
+	}
+	docScore.Explanation.Propagation = string(c.propagation.Name())
+	if len(parents) == 0 {
+		return docScore, nil
+	}
+
+	confidences := make([]float64, 0, len(parents))
+	for _, parent := range parents {
+		if parent.Key == (ulid.ULID{}) {
+			docScore.Parents = append(docScore.Parents, documents.ParentScore{DataRef: parent.DataRef, Missing: true})
+			continue
 		}
+		confidences = append(confidences, parent.Confidence)
+		docScore.Parents = append(docScore.Parents, documents.ParentScore{
+			DataRef:    parent.DataRef,
+			ScoreKey:   parent.Key.String(),
+			Confidence: parent.Confidence,
+		})
+	}
+	docScore.Confidence = math.Round(c.propagation.Propagate(docScore.Confidence, confidences)*100) / 100
+	docScore.Band = p.Band(docScore.Confidence)
+	return docScore, nil
+}
 
-		// Create an edge between the score and the data
-		err = c.dbClient.CreateEdge(ctx, docScore.Key.String(), key, documents.EdgeScoring)
+// storePolicy makes sure the version of the policy is in the database and returns its key. The version is recorded as
+// activated whenever it differs from the last version stored for the policy, including when an edit is reverted.
+func (c *Calculator) storePolicy(ctx context.Context, p policies.DcfPolicy) (string, error) {
+	doc := documents.NewPolicy(p)
+	if key, ok := c.stored.Load(p.Name); ok && key == doc.Key {
+		return doc.Key, nil
+	}
+	err := c.dbClient.CreatePolicy(ctx, doc)
+	if err != nil {
+		return "", err
+	}
+	c.stored.Store(p.Name, doc.Key)
+	return doc.Key, nil
+}
+
+// createStackEdge links a lower layer score to the score built on it. Stand-ins for missing scores are not linked.
+func (c *Calculator) createStackEdge(ctx context.Context, lower documents.Score, upper documents.Score) error {
+	if lower.Key == (ulid.ULID{}) {
+		return nil
+	}
+	return c.dbClient.CreateEdge(ctx, lower.Key.String(), upper.Key.String(), documents.EdgeStack)
+}
+
+// release removes a key from the key store once there is nothing further to do for it. Keys are deliberately left in
+// the store when scoring fails so they will be retried on the next start. A key received again while it was being
+// scored is stored again, since the key store holds each key once and the newer annotations still need a score.
+func (c *Calculator) release(key string) {
+	err := c.keyStore.Remove(key)
+	if err != nil {
+		c.logger.Error(err.Error())
+	}
+	if c.collector.Pending(key) {
+		err = c.keyStore.Add(key)
 		if err != nil {
 			c.logger.Error(err.Error())
-			return
-		}
-
-		for _, tagScore := range tagFieldScores {
-			// Create an edge between the OS score and host score
-			err = c.dbClient.CreateEdge(ctx, tagScore.Key.String(), docScore.Key.String(), documents.EdgeStack)
-			if err != nil {
-				c.logger.Error(err.Error())
-				return
-			}
 		}
+	}
+}
 
-	default:
-		docScore = documents.NewScore(key, annotations, c.policy, tagFieldScores, hostFieldScores)
-		err = c.dbClient.CreateDocument(ctx, docScore.Key.String(), docScore, documents.VertexScores)
+// rescoreDependents queues the dataRefs of the current scores that were built on an earlier score of the same layer and
+// tag, if cascading is enabled, so they pick up the new confidence. If parent confidence is propagated, the data mutated
+// from the dataRef of the score is queued whether or not cascading is enabled, since it may have been scored before its
+// parent. The path holds the keys whose new scores led to this one being calculated and any dependent already on it is
+// skipped, which stops a cascade from going round in circles.
+func (c *Calculator) rescoreDependents(ctx context.Context, score documents.Score, path []string) {
+	var dependents []string
+	var err error
+	if c.cascade.Enabled && len(score.Tag) > 0 {
+		dependents, err = c.dbClient.QueryDependents(ctx, score, c.cascade.FanOut)
 		if err != nil {
 			c.logger.Error(err.Error())
 			return
 		}
-		err = c.dbClient.CreateEdge(ctx, docScore.Key.String(), key, documents.EdgeScoring)
+	}
+	if c.propagation.Name() != config.NoPropagation {
+		derived, err := c.dbClient.QueryDerived(ctx, score.DataRef, c.cascade.FanOut)
 		if err != nil {
 			c.logger.Error(err.Error())
 			return
 		}
+		for _, dataRef := range derived {
+			if !slices.Contains(dependents, dataRef) {
+				dependents = append(dependents, dataRef)
+			}
+		}
+	}
+
+	path = append(path, score.DataRef)
+	for _, dataRef := range dependents {
+		if slices.Contains(path, dataRef) {
+			c.logger.Write(slog.LevelDebug, fmt.Sprintf("cascade cycle detected, not rescoring %s via %v", dataRef, path))
+			continue
+		}
+		c.logger.Write(slog.LevelDebug, fmt.Sprintf("rescoring %s after new %s score for %s", dataRef, score.Layer, score.DataRef))
+		c.cascadePaths.Set(dataRef, path)
+		c.collector.Enqueue(dataRef)
 	}
+}
 
-	c.condition.Signal()
+// newScore applies the configured strategy to the annotations and flags the result as partial if any of the annotation
+// kinds expected for the layer were not received. Any lower layer scores that were missing are recorded on the result.
+func (c *Calculator) newScore(
+	key string,
+	annotations []documents.Annotation,
+	policy policies.DcfPolicy,
+	dependencies []scoring.Dependency,
+	missingScores []documents.MissingScore,
+) documents.Score {
+	docScore := scoring.NewScore(c.strategy, c.decay, key, annotations, policy, dependencies)
+	docScore.Missing = missingScores
+	missing := c.expectations.Missing(annotations)
+	if len(missing) > 0 {
+		docScore.Partial = true
+		c.logger.Write(slog.LevelDebug, fmt.Sprintf("partial score for %s, missing %v", key, missing))
+	}
+	return docScore
 }
@@ -0,0 +1,398 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/decay"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/policy"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/propagation"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/scoring"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/store"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+	"github.com/project-alvarium/scoring-apps-go/pkg/requests"
+)
+
+// newTestCalculator returns a calculator scoring against the fake graph, with the application layer built on the OS
+// layer of the same host.
+func newTestCalculator(
+	t *testing.T,
+	graph *fakeGraph,
+	cascade bool,
+	propagationType config.PropagationType,
+	dcfPolicies ...policies.DcfPolicy,
+) *Calculator {
+	t.Helper()
+	strategy, err := scoring.NewScoringStrategy(config.ScoringInfo{Type: config.WeightedRatio})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decayFn, err := decay.NewDecayFunction(config.DecayInfo{Type: config.NoDecay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	propagationFn, err := propagation.NewPropagationFunction(config.PropagationInfo{Type: propagationType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := types.NewLayerGraph([]config.LayerDependency{
+		{Layer: contracts.Application, DependsOn: contracts.Os, JoinOn: config.JoinHost},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dcfPolicies) == 0 {
+		dcfPolicies = []policies.DcfPolicy{{Name: "default"}}
+	}
+
+	collector := newTestCollector(graph)
+	c := NewCalculator(nil, config.DatabaseInfo{}, CalculatorOptions{
+		Policies:     types.NewPolicySet(dcfPolicies),
+		Strategy:     strategy,
+		Decay:        decayFn,
+		Propagation:  propagationFn,
+		Expectations: types.NewExpectations(config.CollectorInfo{}, dcfPolicies),
+		Layers:       layers,
+		KeyStore:     store.NewMemoryStore(),
+		Collector:    collector,
+		Workers:      1,
+		Cascade:      config.CascadeInfo{Enabled: cascade},
+	}, newTestLogger())
+	c.dbClient = graph
+	return &c
+}
+
+// queued returns the keys waiting in the collector, sorted.
+func queued(c *Calculator) []string {
+	var keys []string
+	for _, s := range c.collector.keyMap.Sightings() {
+		keys = append(keys, s.Key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestRescoreDependents(t *testing.T) {
+	tests := []struct {
+		name        string
+		cascade     bool
+		propagation config.PropagationType
+		path        []string
+		expected    []string
+	}{
+		{"dependents", true, config.NoPropagation, nil, []string{"app-1"}},
+		{"dependents and derived", true, config.MinimumPropagation, nil, []string{"app-1", "os-3"}},
+		{"cycle", true, config.MinimumPropagation, []string{"app-1", "os-3"}, nil},
+		// Derived data may have been scored before its parent, so it is queued even without cascading
+		{"derived without cascade", false, config.MinimumPropagation, nil, []string{"os-3"}},
+		{"disabled", false, config.NoPropagation, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := newFakeGraph()
+			lower := graph.addScore("os-1", contracts.Os, "default", 1, "host-a")
+			upper := graph.addScore("app-1", contracts.Application, "default", 1, "tag-a")
+			_ = graph.CreateEdge(context.Background(), lower.Key.String(), upper.Key.String(), documents.EdgeStack)
+			graph.lineage["os-3"] = []string{"os-2"}
+			newer := graph.addScore("os-2", contracts.Os, "default", 0.5, "host-a")
+
+			c := newTestCalculator(t, graph, tt.cascade, tt.propagation)
+			c.rescoreDependents(context.Background(), newer, tt.path)
+
+			if received := queued(c); !slices.Equal(received, tt.expected) {
+				t.Errorf("expected %v to be queued, received %v", tt.expected, received)
+			}
+			for _, k := range tt.expected {
+				expectedPath := append(slices.Clone(tt.path), newer.DataRef)
+				if path := c.cascadePaths.Take(k); !slices.Equal(path, expectedPath) {
+					t.Errorf("expected path %v for %s, received %v", expectedPath, k, path)
+				}
+			}
+		})
+	}
+}
+
+func TestPropagate(t *testing.T) {
+	tests := []struct {
+		name        string
+		propagation config.PropagationType
+		parents     map[string]float64 // parents holds the confidence of each scored parent
+		unscored    []string
+		expected    float64
+	}{
+		{"no parents", config.MinimumPropagation, nil, nil, 1},
+		{"scored parent", config.MinimumPropagation, map[string]float64{"app-1": 0.5}, nil, 0.5},
+		// Unscored parents are only recorded as missing
+		{"unscored parent", config.MinimumPropagation, nil, []string{"app-1"}, 1},
+		{"scored and unscored parents", config.ProductPropagation, map[string]float64{"app-1": 0.8}, []string{"app-2"}, 0.8},
+		{"no propagation", config.NoPropagation, nil, []string{"app-1"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := newFakeGraph()
+			for dataRef, confidence := range tt.parents {
+				graph.addScore(dataRef, contracts.Application, "default", confidence)
+				graph.lineage["app-3"] = append(graph.lineage["app-3"], dataRef)
+			}
+			// The upstream data of a mutation exists without annotations or a score
+			graph.lineage["app-3"] = append(graph.lineage["app-3"], tt.unscored...)
+
+			c := newTestCalculator(t, graph, false, tt.propagation)
+			docScore := documents.Score{DataRef: "app-3", Layer: contracts.Application, Policy: "default", Confidence: 1,
+				Explanation: &documents.Explanation{}}
+			result, err := c.propagate(context.Background(), docScore, policies.DcfPolicy{Name: "default"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Confidence != tt.expected {
+				t.Errorf("expected confidence %v, received %v", tt.expected, result.Confidence)
+			}
+			if tt.propagation == config.NoPropagation {
+				return
+			}
+
+			if len(result.Parents) != len(tt.parents)+len(tt.unscored) {
+				t.Fatalf("expected %d parents, received %+v", len(tt.parents)+len(tt.unscored), result.Parents)
+			}
+			for _, parent := range result.Parents {
+				if slices.Contains(tt.unscored, parent.DataRef) {
+					if !parent.Missing || parent.ScoreKey != "" || parent.Confidence != 0 {
+						t.Errorf("expected %s to be missing, received %+v", parent.DataRef, parent)
+					}
+				} else if parent.Missing || parent.ScoreKey == "" || parent.Confidence != tt.parents[parent.DataRef] {
+					t.Errorf("expected the score of %s, received %+v", parent.DataRef, parent)
+				}
+			}
+		})
+	}
+}
+
+func TestReleaseKeepsPendingKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		again    bool
+		expected []string
+	}{
+		{"scored", false, nil},
+		{"received again while scoring", true, []string{"app-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCalculator(t, newFakeGraph(), false, config.NoPropagation)
+			c.keyStore = c.collector.keyStore
+			c.collector.Enqueue("app-1")
+			c.collector.keyMap.Release(c.collector.keyMap.Sightings()...)
+			if tt.again {
+				c.collector.Enqueue("app-1")
+			}
+			c.release("app-1")
+
+			keys, err := c.keyStore.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(keys, tt.expected) {
+				t.Errorf("expected %v to be stored, received %v", tt.expected, keys)
+			}
+		})
+	}
+}
+
+func TestDeferMissing(t *testing.T) {
+	c := newTestCalculator(t, newFakeGraph(), false, config.NoPropagation)
+	missing := []documents.MissingScore{
+		{Field: "host-a", Layer: contracts.Os, Penalty: string(config.IgnoreMissing)},
+		{Field: "host-b", Layer: contracts.Os, Penalty: string(config.DeferMissing)},
+	}
+	if !c.deferMissing("app-1", missing) {
+		t.Fatal("expected the key to be deferred")
+	}
+	if keys := c.deferred.Take(contracts.Os, []string{"host-a"}); len(keys) != 0 {
+		t.Errorf("expected nothing to wait for host-a, received %v", keys)
+	}
+	if keys := c.deferred.Take(contracts.Os, []string{"host-b"}); !slices.Equal(keys, []string{"app-1"}) {
+		t.Errorf("expected app-1 to wait for host-b, received %v", keys)
+	}
+	if c.deferMissing("app-2", missing[:1]) {
+		t.Error("expected the key not to be deferred")
+	}
+}
+
+func TestScoreUnderEveryPolicy(t *testing.T) {
+	dcfPolicies := []policies.DcfPolicy{
+		{Name: "default", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 1}, {AnnotationKey: "tls", Value: 1}}},
+		{Name: "strict", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 1, Required: true}, {AnnotationKey: "tls", Value: 1}}},
+	}
+	annotations := []documents.Annotation{
+		{Key: "a1", DataRef: "app-1", Host: "host-a", Layer: contracts.Application, Kind: "tpm", IsSatisfied: false},
+		{Key: "a2", DataRef: "app-1", Host: "host-a", Layer: contracts.Application, Kind: "tls", IsSatisfied: true},
+	}
+
+	tests := []struct {
+		name     string
+		missing  map[contracts.LayerType]config.MissingInfo
+		expected map[string]float64 // expected holds the confidence stored under each policy
+	}{
+		{"both", nil, map[string]float64{"default": 0.5, "strict": 0}},
+		// The OS has only been scored under the default policy, so the key waits under both
+		{"deferred", map[contracts.LayerType]config.MissingInfo{contracts.Os: {Action: config.DeferMissing}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := newFakeGraph()
+			graph.annotations["app-1"] = annotations
+			lower := graph.addScore("os-1", contracts.Os, "default", 1, "host-a")
+
+			c := newTestCalculator(t, graph, false, config.NoPropagation, dcfPolicies...)
+			c.missing = tt.missing
+			c.score(context.Background(), "app-1")
+
+			current := graph.current("app-1")
+			if len(current) != len(tt.expected) {
+				t.Fatalf("expected %d current scores, received %d", len(tt.expected), len(current))
+			}
+			for _, s := range current {
+				confidence, ok := tt.expected[s.Policy]
+				if !ok || s.Confidence != confidence {
+					t.Errorf("expected confidence %v under %s, received %v", confidence, s.Policy, s.Confidence)
+				}
+				if graph.weighting[s.Key] != s.Explanation.PolicyKey || s.Explanation.PolicyKey == "" {
+					t.Errorf("expected the %s score to be linked to its policy version", s.Policy)
+				}
+				// Only the score under the policy the OS was scored with is stacked on it
+				stacked := slices.Contains(graph.stack[lower.Key], s.Key)
+				if stacked != (s.Policy == "default") {
+					t.Errorf("expected the %s score stacked on the OS score to be %v", s.Policy, s.Policy == "default")
+				}
+			}
+			if tt.expected == nil {
+				if keys := c.deferred.Take(contracts.Os, []string{"host-a"}); !slices.Equal(keys, []string{"app-1"}) {
+					t.Errorf("expected app-1 to wait for host-a, received %v", keys)
+				}
+			}
+		})
+	}
+}
+
+func TestScoreLookupFailure(t *testing.T) {
+	graph := newFakeGraph()
+	graph.annotations["app-1"] = []documents.Annotation{
+		{Key: "a1", DataRef: "app-1", Host: "host-a", Layer: contracts.Application, Kind: "tpm", IsSatisfied: true},
+	}
+	graph.failing["host-a"] = errors.New("connection refused")
+
+	c := newTestCalculator(t, graph, false, config.NoPropagation)
+	c.keyStore = c.collector.keyStore
+	c.collector.Enqueue("app-1")
+	c.collector.keyMap.Release(c.collector.keyMap.Sightings()...)
+	c.score(context.Background(), "app-1")
+
+	// Nothing is written from the layers that could be looked up, and the key is kept for a retry
+	if len(graph.scores) != 0 {
+		t.Errorf("expected no scores to be written, received %v", graph.scores)
+	}
+	keys, err := c.keyStore.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keys, []string{"app-1"}) {
+		t.Errorf("expected app-1 to be kept in the key store, received %v", keys)
+	}
+}
+
+func TestStorePolicyActivatesReverts(t *testing.T) {
+	a := policies.DcfPolicy{Name: "default", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 1}}}
+	b := policies.DcfPolicy{Name: "default", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 2}}}
+	graph := newFakeGraph()
+	c := newTestCalculator(t, graph, false, config.NoPropagation)
+
+	var keys []string
+	for _, p := range []policies.DcfPolicy{a, a, b, a} {
+		key, err := c.storePolicy(context.Background(), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+		time.Sleep(time.Millisecond)
+	}
+
+	if keys[0] != keys[1] || keys[0] != keys[3] || keys[0] == keys[2] {
+		t.Fatalf("expected the reverted policy to keep its version, received %v", keys)
+	}
+	if len(graph.policies) != 2 {
+		t.Fatalf("expected 2 versions, received %d", len(graph.policies))
+	}
+	first, second := graph.policies[keys[0]], graph.policies[keys[2]]
+	if !first.Timestamp.Before(second.Timestamp) {
+		t.Error("expected the reverted version to keep the time it was first stored")
+	}
+	if !first.Activated.After(second.Activated) {
+		t.Error("expected the reverted version to be the most recently activated")
+	}
+}
+
+// fakeDecider decides the same weights for every piece of data.
+type fakeDecider struct {
+	weights []policies.Weight
+}
+
+func (d fakeDecider) Decide(ctx context.Context, input requests.OpaDecisionInput) (policy.Decision, error) {
+	return policy.Decision{Weights: d.weights}, nil
+}
+
+func TestScoreKeepsDecidedWeightsOffPolicyHistory(t *testing.T) {
+	configured := policies.DcfPolicy{Name: "default", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 1}}}
+	decided := []policies.Weight{{AnnotationKey: "tpm", Value: 5}}
+	graph := newFakeGraph()
+	for _, key := range []string{"app-1", "app-2"} {
+		graph.annotations[key] = []documents.Annotation{
+			{Key: key + "-a1", DataRef: key, Layer: contracts.Os, Kind: "tpm", IsSatisfied: true},
+		}
+	}
+
+	c := newTestCalculator(t, graph, false, config.NoPropagation, configured)
+	c.decider = fakeDecider{weights: decided}
+	c.score(context.Background(), "app-1")
+	c.score(context.Background(), "app-2")
+
+	expectedKey := documents.NewPolicy(configured).Key
+	if _, ok := graph.policies[expectedKey]; !ok || len(graph.policies) != 1 {
+		t.Fatalf("expected only the configured policy to be stored, received %v", graph.policies)
+	}
+	for _, key := range []string{"app-1", "app-2"} {
+		current := graph.current(key)
+		if len(current) != 1 {
+			t.Fatalf("expected a score for %s", key)
+		}
+		explanation := current[0].Explanation
+		if explanation.PolicyKey != expectedKey {
+			t.Errorf("expected %s to be linked to the configured policy", key)
+		}
+		if !reflect.DeepEqual(explanation.DecidedWeights, decided) {
+			t.Errorf("expected the decided weights %v on the explanation, received %v", decided,
+				explanation.DecidedWeights)
+		}
+	}
+}
@@ -16,39 +16,86 @@ package calculator
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/store"
 	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
 )
 
 const (
-	pollingInterval int64 = 2000 // This could be moved into config
+	pollingInterval int64 = 2000 // Applies to layers without expected annotation kinds
 	tickInterval    int64 = 500
+	defaultMaxWait  int64 = 30000
 )
 
 // Collector is responsible for maintaining a map of all of the dequeued keys. It collects these keys in order to
 // de-duplicate them so we don't calculate the score for the same key more than once (hopefully) or otherwise when
-// the annotations are incomplete.
+// the annotations are incomplete. A key is released for scoring as soon as all of the annotation kinds expected for
+// its layer have been received, or once the configured maximum wait has elapsed.
 type Collector struct {
-	chPub  chan string
-	chSub  chan string
-	logger interfaces.Logger
-	keyMap *types.KeyMap
+	chPub        chan string
+	chSub        chan string
+	dbConfig     config.DatabaseInfo
+	dbClient     GraphClient
+	expectations *types.Expectations
+	logger       interfaces.Logger
+	keyMap       *types.KeyMap
+	keyStore     store.KeyStore
+	maxWait      int64
 }
 
-func NewCollector(chKeys chan string, chPub chan string, logger interfaces.Logger) Collector {
+func NewCollector(
+	chKeys chan string,
+	chPub chan string,
+	dbConfig config.DatabaseInfo,
+	cfg config.CollectorInfo,
+	expectations *types.Expectations,
+	keyStore store.KeyStore,
+	logger interfaces.Logger,
+) Collector {
+	maxWait := cfg.MaxWait
+	if maxWait <= 0 {
+		maxWait = defaultMaxWait
+	}
 	return Collector{
-		chPub:  chPub,
-		chSub:  chKeys,
-		logger: logger,
-		keyMap: types.NewKeyMap(),
+		chPub:        chPub,
+		chSub:        chKeys,
+		dbConfig:     dbConfig,
+		expectations: expectations,
+		logger:       logger,
+		keyMap:       types.NewKeyMap(),
+		keyStore:     keyStore,
+		maxWait:      maxWait,
 	}
 }
 
 func (c *Collector) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bool {
+	db, err := NewArangoClient(c.dbConfig, c.logger)
+	if err != nil {
+		c.logger.Error(err.Error())
+		return false
+	}
+	c.dbClient = db
+
+	// Keys left over from a previous run need to be scored again
+	keys, err := c.keyStore.Load()
+	if err != nil {
+		c.logger.Error(err.Error())
+		return false
+	}
+	if len(keys) > 0 {
+		c.logger.Write(slog.LevelInfo, fmt.Sprintf("recovered %v outstanding keys", len(keys)))
+	}
+	for _, k := range keys {
+		c.keyMap.Add(k)
+	}
+
 	wg.Add(1)
 	go func() { // Process messages
 		defer wg.Done()
@@ -59,25 +106,31 @@ func (c *Collector) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bo
 				return
 			}
 
-			c.keyMap.Add(msg)
+			c.Enqueue(msg)
 		}
 	}()
 
-	cancelled := false
 	wg.Add(1)
-	go func() { // Process messages
+	go func() { // Release keys that are ready for scoring
 		defer wg.Done()
+		defer close(c.chPub)
 
+		ticker := time.NewTicker(time.Millisecond * time.Duration(tickInterval))
+		defer ticker.Stop()
 		for {
-			if !cancelled {
-				time.Sleep(time.Millisecond * time.Duration(tickInterval))
-				keys := c.keyMap.Poll(pollingInterval)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				keys := c.ready(ctx)
 				for _, k := range keys {
-					c.chPub <- k
+					select {
+					case c.chPub <- k:
+					case <-ctx.Done():
+						// Undelivered keys remain in the key store
+						return
+					}
 				}
-			} else {
-				close(c.chPub)
-				return
 			}
 		}
 	}()
@@ -87,8 +140,70 @@ func (c *Collector) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bo
 		defer wg.Done()
 
 		<-ctx.Done()
-		cancelled = true
 		c.logger.Write(slog.LevelInfo, "shutdown received")
 	}()
 	return true
 }
+
+// Enqueue records a key that needs to be scored. It is safe to call from any goroutine. The key is added to the key map
+// before the key store, so that a score of the key finishing in between sees it as pending and keeps it stored.
+func (c *Collector) Enqueue(key string) {
+	c.keyMap.Add(key)
+	err := c.keyStore.Add(key)
+	if err != nil {
+		c.logger.Error(err.Error())
+	}
+}
+
+// Pending indicates whether the key is waiting to be released for scoring.
+func (c *Collector) Pending(key string) bool {
+	return c.keyMap.Has(key)
+}
+
+// ready returns the keys that can be released for scoring and removes them from the key map. The annotations of all
+// pending keys are read in one query per tick. A key received again while its annotations were being read stays in the
+// key map until the next tick.
+func (c *Collector) ready(ctx context.Context) []string {
+	sightings := c.keyMap.Sightings()
+	if len(sightings) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(sightings))
+	for _, item := range sightings {
+		keys = append(keys, item.Key)
+	}
+	// If the annotations cannot be read, only the keys that have waited long enough are released
+	annotations, err := c.dbClient.QueryAnnotationsByKeys(ctx, keys)
+	if err != nil {
+		c.logger.Error(err.Error())
+	}
+
+	var found []types.KeySighting
+	for _, item := range sightings {
+		if c.isReady(item, annotations[item.Key]) {
+			found = append(found, item)
+		}
+	}
+	return c.keyMap.Release(found...)
+}
+
+// isReady decides whether a key can be released given the annotations received for it so far.
+func (c *Collector) isReady(item types.KeySighting, annotations []documents.Annotation) bool {
+	timedOut := time.Since(item.FirstSeen).Milliseconds() >= c.maxWait
+	if len(annotations) == 0 {
+		return timedOut
+	}
+
+	if !c.expectations.Defined(annotations[0].Layer) {
+		return time.Since(item.LastSeen).Milliseconds() >= pollingInterval
+	}
+
+	missing := c.expectations.Missing(annotations)
+	if len(missing) == 0 {
+		return true
+	}
+	if timedOut {
+		c.logger.Write(slog.LevelDebug, fmt.Sprintf("max wait elapsed for %s, missing %v", item.Key, missing))
+	}
+	return timedOut
+}
@@ -0,0 +1,98 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/store"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+func newTestCollector(graph *fakeGraph) *Collector {
+	cfg := config.CollectorInfo{
+		MaxWait:  1000,
+		Expected: map[contracts.LayerType][]string{contracts.Application: {"tpm", "tls"}},
+	}
+	c := NewCollector(nil, nil, config.DatabaseInfo{}, cfg, types.NewExpectations(cfg, nil), store.NewMemoryStore(),
+		newTestLogger())
+	c.dbClient = graph
+	return &c
+}
+
+func TestCollectorIsReady(t *testing.T) {
+	now := time.Now()
+	complete := []documents.Annotation{
+		{Kind: "tpm", Layer: contracts.Application},
+		{Kind: "tls", Layer: contracts.Application},
+	}
+	incomplete := complete[:1]
+	undefined := []documents.Annotation{{Kind: "tpm", Layer: contracts.Os}}
+
+	tests := []struct {
+		name        string
+		firstSeen   time.Time
+		lastSeen    time.Time
+		annotations []documents.Annotation
+		expected    bool
+	}{
+		{"complete", now, now, complete, true},
+		{"incomplete", now, now, incomplete, false},
+		{"incomplete timed out", now.Add(-2 * time.Second), now, incomplete, true},
+		{"no annotations", now, now, nil, false},
+		{"no annotations timed out", now.Add(-2 * time.Second), now, nil, true},
+		{"nothing expected recently seen", now, now, undefined, false},
+		{"nothing expected quiet", now.Add(-3 * time.Second), now.Add(-3 * time.Second), undefined, true},
+	}
+	c := newTestCollector(newFakeGraph())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := types.KeySighting{Key: "key", FirstSeen: tt.firstSeen, LastSeen: tt.lastSeen}
+			if ready := c.isReady(item, tt.annotations); ready != tt.expected {
+				t.Errorf("expected ready %v, received %v", tt.expected, ready)
+			}
+		})
+	}
+}
+
+func TestCollectorReadyQueriesOncePerTick(t *testing.T) {
+	graph := newFakeGraph()
+	graph.annotations["a"] = []documents.Annotation{
+		{DataRef: "a", Kind: "tpm", Layer: contracts.Application},
+		{DataRef: "a", Kind: "tls", Layer: contracts.Application},
+	}
+	graph.annotations["b"] = []documents.Annotation{{DataRef: "b", Kind: "tpm", Layer: contracts.Application}}
+	c := newTestCollector(graph)
+	for _, k := range []string{"a", "b", "c"} {
+		c.Enqueue(k)
+	}
+
+	found := c.ready(context.Background())
+	if !slices.Equal(found, []string{"a"}) {
+		t.Errorf("expected only a to be ready, received %v", found)
+	}
+	if graph.queries != 1 {
+		t.Errorf("expected a single query for all pending keys, received %d", graph.queries)
+	}
+	if remaining := len(c.keyMap.Sightings()); remaining != 2 {
+		t.Errorf("expected 2 keys to remain pending, received %d", remaining)
+	}
+}
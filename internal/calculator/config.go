@@ -18,14 +18,27 @@ import (
 	"encoding/json"
 
 	sdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
 	"github.com/project-alvarium/scoring-apps-go/internal/config"
 )
 
 type ApplicationConfig struct {
-	Database config.DatabaseInfo   `json:"database,omitempty"`
-	Stream   config.PubSubInfo     `json:"stream,omitempty"`
-	Logging  sdkConfig.LoggingInfo `json:"logging,omitempty"`
-	Policy   config.PolicyInfo     `json:"policy,omitempty"`
+	Database    config.DatabaseInfo                        `json:"database,omitempty"`
+	Stream      config.PubSubInfo                          `json:"stream,omitempty"`
+	Logging     sdkConfig.LoggingInfo                      `json:"logging,omitempty"`
+	Policy      config.PolicyInfo                          `json:"policy,omitempty"`
+	Classifiers []string                                   `json:"classifiers,omitempty"` // Classifiers lists the policies to score under, overriding the mode flag
+	Reload      config.ReloadInfo                          `json:"reload,omitempty"`
+	Scoring     config.ScoringInfo                         `json:"scoring,omitempty"`
+	Collector   config.CollectorInfo                       `json:"collector,omitempty"`
+	Queue       config.QueueInfo                           `json:"queue,omitempty"`
+	Workers     int                                        `json:"workers,omitempty"` // Workers is the number of keys scored concurrently
+	Cascade     config.CascadeInfo                         `json:"cascade,omitempty"`
+	Decay       config.DecayInfo                           `json:"decay,omitempty"`
+	Propagation config.PropagationInfo                     `json:"propagation,omitempty"`
+	Missing     map[contracts.LayerType]config.MissingInfo `json:"missing,omitempty"` // Missing is keyed by the lower layer whose score is missing
+	Stack       []config.LayerDependency                   `json:"stack,omitempty"`   // Stack declares which layers are built on which
+	DeadLetter  config.DeadLetterInfo                      `json:"deadLetter,omitempty"`
 }
 
 func (a ApplicationConfig) AsString() string {
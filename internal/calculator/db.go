@@ -18,12 +18,15 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/arangodb/go-driver"
 	"github.com/arangodb/go-driver/http"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
 	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/db"
 	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
 )
 
@@ -124,6 +127,233 @@ func (c *ArangoClient) CreateEdge(ctx context.Context, src string, target string
 	return err
 }
 
+// CreatePolicy stores a version of a policy and records that it was activated. If the version already exists, only its
+// activation time is moved forward, so the time it was first stored is kept.
+func (c *ArangoClient) CreatePolicy(ctx context.Context, policy documents.Policy) error {
+	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	query := `
+      UPSERT { _key: @policy._key }
+           INSERT @policy
+           UPDATE { activated: @policy.activated }
+           IN @@policies
+	 `
+	bindVars := map[string]interface{}{
+		"policy":    policy,
+		"@policies": documents.VertexPolicies,
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return err
+	}
+	return cursor.Close()
+}
+
+// scoreVersionAttempts is how many times a score is written before a conflict with concurrent writes is returned.
+const scoreVersionAttempts int = 5
+
+// CreateScore persists a new version of the score for its dataRef and policy along with the edges linking it to the data
+// and to the version of the policy that produced it. Any previous version is no longer flagged as current and is linked
+// from the new version by a "supersedes" edge. All of this happens in a single transaction so that readers never observe
+// zero or multiple current versions. Concurrent scorings of the same dataRef and policy, such as a cascade requeue
+// racing a fresh message, may read the same latest version. The unique index on the version lets only one of them
+// store it, and the others are written again on top of it.
+func (c *ArangoClient) CreateScore(ctx context.Context, score documents.Score, policyKey string) (documents.Score, error) {
+	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return score, err
+	}
+	return retryConflicts(scoreVersionAttempts, func() (documents.Score, error) {
+		return c.createScore(ctx, db, score, policyKey)
+	}, c.logger)
+}
+
+// retryConflicts calls create until it succeeds, fails on anything but a conflict with a concurrent write, or has been
+// called the given number of times.
+func retryConflicts(
+	attempts int,
+	create func() (documents.Score, error),
+	logger interfaces.Logger,
+) (documents.Score, error) {
+	for attempt := 1; ; attempt++ {
+		score, err := create()
+		if err == nil || !driver.IsConflict(err) || attempt >= attempts {
+			return score, err
+		}
+		logger.Write(slog.LevelDebug, fmt.Sprintf("version %d of the score for %s was written concurrently, retrying",
+			score.Version, score.DataRef))
+	}
+}
+
+// createScore writes the score as the next version in its own transaction.
+func (c *ArangoClient) createScore(
+	ctx context.Context,
+	db driver.Database,
+	score documents.Score,
+	policyKey string,
+) (documents.Score, error) {
+	cols := driver.TransactionCollections{
+		Write: []string{documents.VertexScores, documents.EdgeScoring, documents.EdgeSupersedes, documents.EdgeWeighting},
+	}
+	tid, err := db.BeginTransaction(ctx, cols, nil)
+	if err != nil {
+		return score, err
+	}
+	tctx := driver.WithTransactionID(ctx, tid)
+
+	score, err = c.createScoreVersion(tctx, db, score)
+	if err == nil {
+		err = c.createWeighting(tctx, db, score, policyKey)
+	}
+	if err != nil {
+		abortErr := db.AbortTransaction(ctx, tid, nil)
+		if abortErr != nil {
+			c.logger.Error(abortErr.Error())
+		}
+		return score, err
+	}
+	return score, db.CommitTransaction(ctx, tid, nil)
+}
+
+func (c *ArangoClient) createScoreVersion(ctx context.Context, db driver.Database, score documents.Score) (documents.Score, error) {
+	// Scores written before versioning was introduced have no "current" attribute and are treated as current.
+	query := `
+      FOR s IN scores
+           FILTER s.dataRef == @dataRef AND s.policy == @policy
+           SORT s.version DESC, s.timestamp DESC
+           RETURN s
+	 `
+	bindVars := map[string]interface{}{
+		"dataRef": score.DataRef,
+		"policy":  score.Policy,
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return score, err
+	}
+	defer cursor.Close()
+
+	var previous []documents.Score
+	for {
+		var doc documents.Score
+		_, err := cursor.ReadDocument(ctx, &doc)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return score, err
+		}
+		previous = append(previous, doc)
+	}
+
+	score, superseded := nextVersion(score, previous)
+
+	scores, err := db.Collection(ctx, documents.VertexScores)
+	if err != nil {
+		return score, err
+	}
+	_, err = scores.CreateDocument(ctx, score)
+	if err != nil {
+		return score, err
+	}
+
+	scoring, err := db.Collection(ctx, documents.EdgeScoring)
+	if err != nil {
+		return score, err
+	}
+	_, err = scoring.CreateDocument(ctx, documents.Scoring{
+		From: fmt.Sprintf("%s/%s", documents.VertexScores, score.Key.String()),
+		To:   fmt.Sprintf("%s/%s", documents.VertexData, score.DataRef),
+	})
+	if err != nil {
+		return score, err
+	}
+
+	if len(previous) == 0 {
+		return score, nil
+	}
+
+	supersedes, err := db.Collection(ctx, documents.EdgeSupersedes)
+	if err != nil {
+		return score, err
+	}
+	_, err = supersedes.CreateDocument(ctx, documents.Supersedes{
+		From: fmt.Sprintf("%s/%s", documents.VertexScores, score.Key.String()),
+		To:   fmt.Sprintf("%s/%s", documents.VertexScores, previous[0].Key.String()),
+	})
+	if err != nil {
+		return score, err
+	}
+
+	patch := map[string]interface{}{"current": false}
+	for _, p := range superseded {
+		_, err = scores.UpdateDocument(ctx, p.Key.String(), patch)
+		if err != nil {
+			return score, err
+		}
+	}
+	return score, nil
+}
+
+// nextVersion numbers a new score following the previous versions of the same dataRef and policy, latest first, and
+// returns the previous versions that are still flagged as current and must be cleared. Every one of them is cleared, so
+// that duplicate current versions left behind by earlier concurrent writes are repaired by the next version.
+func nextVersion(score documents.Score, previous []documents.Score) (documents.Score, []documents.Score) {
+	score.Version = 1
+	score.Current = true
+	if len(previous) > 0 {
+		score.Version = previous[0].Version + 1
+	}
+
+	var superseded []documents.Score
+	for _, p := range previous {
+		if p.Version > 0 && !p.Current {
+			continue
+		}
+		superseded = append(superseded, p)
+	}
+	return score, superseded
+}
+
+// EnsureIndexes creates the indexes the calculator relies on if they do not exist yet. The unique index on the
+// version of a score guarantees that no two versions with the same number are stored for a dataRef and policy, which
+// is what keeps concurrent writes of a score apart. It is sparse so that scores written before versioning, which
+// have no version, are left out. The tag index serves the lookups of scores by tag, layer and policy made for every
+// annotation and every cascade.
+func (c *ArangoClient) EnsureIndexes(ctx context.Context) error {
+	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	scores, err := db.Collection(ctx, documents.VertexScores)
+	if err != nil {
+		return err
+	}
+	_, _, err = scores.EnsurePersistentIndex(ctx, []string{"dataRef", "policy", "version"},
+		&driver.EnsurePersistentIndexOptions{Name: "idx_scores_version", Unique: true, Sparse: true})
+	if err != nil {
+		return err
+	}
+	_, _, err = scores.EnsurePersistentIndex(ctx, []string{"tag[*]", "layer", "policy"},
+		&driver.EnsurePersistentIndexOptions{Name: "idx_scores_tag"})
+	return err
+}
+
+func (c *ArangoClient) createWeighting(ctx context.Context, db driver.Database, score documents.Score, policyKey string) error {
+	weighting, err := db.Collection(ctx, documents.EdgeWeighting)
+	if err != nil {
+		return err
+	}
+	_, err = weighting.CreateDocument(ctx, documents.Weighting{
+		From: fmt.Sprintf("%s/%s", documents.VertexScores, score.Key.String()),
+		To:   fmt.Sprintf("%s/%s", documents.VertexPolicies, policyKey),
+	})
+	return err
+}
+
 func (c *ArangoClient) QueryAnnotations(ctx context.Context, key string) ([]documents.Annotation, error) {
 	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
 	if err != nil {
@@ -153,6 +383,35 @@ func (c *ArangoClient) QueryAnnotations(ctx context.Context, key string) ([]docu
 	return annotations, nil
 }
 
+func (c *ArangoClient) QueryAnnotationsByKeys(ctx context.Context, keys []string) (map[string][]documents.Annotation, error) {
+	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+	query := `FOR a in annotations FILTER a.dataRef IN @keys RETURN KEEP(a, "dataRef", "layer", "type")`
+	bindVars := map[string]interface{}{
+		"keys": keys,
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	annotations := make(map[string][]documents.Annotation)
+	for {
+		var doc documents.Annotation
+		_, err := cursor.ReadDocument(ctx, &doc)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		annotations[doc.DataRef] = append(annotations[doc.DataRef], doc)
+	}
+	return annotations, nil
+}
+
 func (c *ArangoClient) ValidateGraph(ctx context.Context) error {
 	exists, err := c.client.DatabaseExists(ctx, c.cfg.DatabaseName)
 	if err != nil {
@@ -200,45 +459,231 @@ func (c *ArangoClient) ValidateGraph(ctx context.Context) error {
 	return nil
 }
 
-func (c *ArangoClient) QueryScoreByTag(ctx context.Context, tag string, layer contracts.LayerType) (documents.Score, error) {
-	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
+// QueryScoreByTag returns the current score of the layer for the tag or host value under the policy, or
+// db.ErrScoreNotFound if there is none.
+func (c *ArangoClient) QueryScoreByTag(
+	ctx context.Context,
+	tag string,
+	layer contracts.LayerType,
+	policy string,
+) (documents.Score, error) {
+	database, err := c.client.Database(ctx, c.cfg.DatabaseName)
 	if err != nil {
 		return documents.Score{}, err
 	}
+	return db.QueryScoreByTag(ctx, database, tag, layer, policy)
+}
+
+// QueryDependents returns the dataRefs of the current scores built on top of an earlier score from the same layer,
+// tag and policy as the supplied score. These are the scores that need to be recalculated now that a newer score exists.
+// The lower scores are looked up by tag through the tag index created by EnsureIndexes rather than a scan of all scores.
+func (c *ArangoClient) QueryDependents(ctx context.Context, score documents.Score, limit int) ([]string, error) {
+	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
 
 	query := `
-      FOR s in scores
-           FILTER @tag IN s.tag AND s.layer == @layer AND s.confidence != null
-           SORT s.timestamp DESC
-           LIMIT 1
-           RETURN s
+      FOR tag IN @tags
+           FOR lower IN scores
+                FILTER tag IN lower.tag[*] AND lower.layer == @layer AND lower.policy == @policy AND lower._key != @key
+                FOR upper IN 1..1 OUTBOUND lower @@stack
+                     FILTER upper.current != false
+                     COLLECT dataRef = upper.dataRef
+                     LIMIT @limit
+                     RETURN dataRef
 	 `
 	bindVars := map[string]interface{}{
-		"tag":   tag,
-		"layer": layer,
+		"layer":  score.Layer,
+		"policy": score.Policy,
+		"key":    score.Key.String(),
+		"tags":   score.Tag,
+		"@stack": documents.EdgeStack,
+		"limit":  limit,
 	}
 	cursor, err := db.Query(ctx, query, bindVars)
 	if err != nil {
-		return documents.Score{}, err
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var dataRefs []string
+	for {
+		var dataRef string
+		_, err := cursor.ReadDocument(ctx, &dataRef)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		dataRefs = append(dataRefs, dataRef)
+	}
+	return dataRefs, nil
+}
+
+// QueryParentScores returns the most recent current score, for the layer and policy, of each piece of data the supplied
+// dataRef was mutated from. A parent that has not been scored, such as the upstream data of a mutation that was never
+// annotated, is returned as a score without a key that only holds its dataRef.
+func (c *ArangoClient) QueryParentScores(
+	ctx context.Context,
+	dataRef string,
+	layer contracts.LayerType,
+	policy string,
+) ([]documents.Score, error) {
+	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+      FOR parent IN 1..1 OUTBOUND @data @@lineage
+           LET latest = FIRST(
+                FOR s IN scores
+                     FILTER s.dataRef == parent._key AND s.layer == @layer AND s.policy == @policy
+                     FILTER s.confidence != null AND s.current != false
+                     SORT s.timestamp DESC
+                     LIMIT 1
+                     RETURN s
+           )
+           SORT parent._key
+           RETURN latest != null ? latest : { dataRef: parent._key }
+	 `
+	bindVars := map[string]interface{}{
+		"data":     fmt.Sprintf("%s/%s", documents.VertexData, dataRef),
+		"@lineage": documents.EdgeLineage,
+		"layer":    layer,
+		"policy":   policy,
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return nil, err
 	}
 	defer cursor.Close()
 
-	// There should only be one document returned here
-	var score documents.Score
-	foundScore := false
+	var scores []documents.Score
 	for {
+		var score documents.Score
 		_, err := cursor.ReadDocument(ctx, &score)
 		if driver.IsNoMoreDocuments(err) {
 			break
 		} else if err != nil {
-			return documents.Score{}, err
+			return nil, err
 		}
-		foundScore = true
+		scores = append(scores, score)
 	}
+	return scores, nil
+}
 
-	if !foundScore {
-		return documents.Score{}, fmt.Errorf("no document found for tag: %s", tag)
+// QueryDerived returns the keys of the data mutated from the supplied dataRef. These need to be recalculated when the
+// confidence of the dataRef changes, if parent confidence is propagated.
+func (c *ArangoClient) QueryDerived(ctx context.Context, dataRef string, limit int) ([]string, error) {
+	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
 	}
 
-	return score, nil
+	query := `
+      FOR child IN 1..1 INBOUND @data @@lineage
+           LIMIT @limit
+           RETURN child._key
+	 `
+	bindVars := map[string]interface{}{
+		"data":     fmt.Sprintf("%s/%s", documents.VertexData, dataRef),
+		"@lineage": documents.EdgeLineage,
+		"limit":    limit,
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var dataRefs []string
+	for {
+		var key string
+		_, err := cursor.ReadDocument(ctx, &key)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		dataRefs = append(dataRefs, key)
+	}
+	return dataRefs, nil
+}
+
+// QueryUnscoredData returns the keys of the data vertexes that lack a current score under at least one of the named
+// policies, oldest first.
+func (c *ArangoClient) QueryUnscoredData(
+	ctx context.Context,
+	filter types.BackfillFilter,
+	policies []string,
+) ([]string, error) {
+	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	query, bindVars := unscoredDataQuery(filter, policies)
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var keys []string
+	for {
+		var key string
+		_, err := cursor.ReadDocument(ctx, &key)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// unscoredDataQuery builds the query for the data matching the backfill filter that is missing a current score under
+// any of the policies, so that data scored before a policy was added is picked up for it. With a host, the annotations
+// made on the host are collected into their dataRefs first and joined to the data, rather than searching the
+// annotations of every data vertex.
+func unscoredDataQuery(filter types.BackfillFilter, policies []string) (string, map[string]interface{}) {
+	bindVars := map[string]interface{}{
+		"@scoring": documents.EdgeScoring,
+		"policies": policies,
+	}
+
+	query := `
+      FOR d IN data`
+	if filter.Host != "" {
+		query = `
+      FOR a IN annotations
+           FILTER a.host == @host
+           COLLECT dataRef = a.dataRef
+           FOR d IN data
+                FILTER d._key == dataRef`
+		bindVars["host"] = filter.Host
+	}
+	if !filter.From.IsZero() {
+		query += `
+           FILTER DATE_TIMESTAMP(d.timestamp) >= DATE_TIMESTAMP(@from)`
+		bindVars["from"] = filter.From.Format(time.RFC3339Nano)
+	}
+	if !filter.To.IsZero() {
+		query += `
+           FILTER DATE_TIMESTAMP(d.timestamp) <= DATE_TIMESTAMP(@to)`
+		bindVars["to"] = filter.To.Format(time.RFC3339Nano)
+	}
+	query += `
+           FILTER LENGTH(
+                FOR s IN 1..1 INBOUND d @@scoring
+                     FILTER s.current != false AND s.policy IN @policies
+                     RETURN DISTINCT s.policy
+           ) < LENGTH(@policies)
+           SORT d.timestamp
+           RETURN d._key
+	 `
+	return query, bindVars
 }
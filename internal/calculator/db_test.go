@@ -0,0 +1,133 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arangodb/go-driver"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// scoreVersions mimics the version queries of createScoreVersion against an in-memory scores collection.
+type scoreVersions []documents.Score
+
+// create stores a new version of a score the way a createScoreVersion transaction that commits does.
+func (v *scoreVersions) create(dataRef string) documents.Score {
+	var previous []documents.Score
+	for i := len(*v) - 1; i >= 0; i-- {
+		if (*v)[i].DataRef == dataRef {
+			previous = append(previous, (*v)[i])
+		}
+	}
+
+	score, superseded := nextVersion(documents.Score{Key: documents.NewULID(), DataRef: dataRef}, previous)
+	for _, s := range superseded {
+		for i := range *v {
+			if (*v)[i].Key == s.Key {
+				(*v)[i].Current = false
+			}
+		}
+	}
+	*v = append(*v, score)
+	return score
+}
+
+func (v *scoreVersions) current(dataRef string) []documents.Score {
+	var found []documents.Score
+	for _, s := range *v {
+		if s.DataRef == dataRef && s.Current {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		existing        scoreVersions
+		expectedVersion int
+	}{
+		{"first", nil, 1},
+		// Scores written before versioning have no current attribute and are read as current
+		{"unversioned", scoreVersions{{Key: documents.NewULID(), DataRef: "a", Current: true}}, 1},
+		{"following", scoreVersions{
+			{Key: documents.NewULID(), DataRef: "a", Version: 1},
+			{Key: documents.NewULID(), DataRef: "a", Version: 2, Current: true},
+		}, 3},
+		{"duplicate current", scoreVersions{
+			{Key: documents.NewULID(), DataRef: "a", Version: 1},
+			{Key: documents.NewULID(), DataRef: "a", Version: 2, Current: true},
+			{Key: documents.NewULID(), DataRef: "a", Version: 2, Current: true},
+		}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := append(scoreVersions{}, tt.existing...)
+			// A cascade requeue and a fresh message scoring the same dataRef, the second retried after a conflict
+			first := versions.create("a")
+			second := versions.create("a")
+
+			if first.Version != tt.expectedVersion {
+				t.Errorf("expected version %d, received %d", tt.expectedVersion, first.Version)
+			}
+			if second.Version != first.Version+1 {
+				t.Errorf("expected version %d, received %d", first.Version+1, second.Version)
+			}
+			current := versions.current("a")
+			if len(current) != 1 {
+				t.Fatalf("expected a single current version, received %d", len(current))
+			}
+			if current[0].Key != second.Key {
+				t.Errorf("expected the latest version to be current, received version %d", current[0].Version)
+			}
+		})
+	}
+}
+
+func TestRetryConflicts(t *testing.T) {
+	conflict := driver.ArangoError{HasError: true, Code: 409, ErrorNum: driver.ErrArangoUniqueConstraintViolated}
+	tests := []struct {
+		name     string
+		errs     []error // errs are returned by successive attempts, after which the write succeeds
+		attempts int
+		fails    bool
+	}{
+		{"written", nil, 1, false},
+		{"written after conflicts", []error{conflict, conflict}, 3, false},
+		{"conflicts exhausted", []error{conflict, conflict, conflict, conflict, conflict}, 5, true},
+		{"other error", []error{errors.New("unavailable")}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			_, err := retryConflicts(scoreVersionAttempts, func() (documents.Score, error) {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return documents.Score{}, tt.errs[attempts-1]
+				}
+				return documents.Score{}, nil
+			}, newTestLogger())
+			if (err != nil) != tt.fails {
+				t.Errorf("unexpected error %v", err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("expected %d attempts, received %d", tt.attempts, attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package decay
+
+import (
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// CutoffDecay keeps confidence at its full value until maxAge, after which the score no longer counts at all.
+type CutoffDecay struct {
+	maxAge time.Duration
+}
+
+func NewCutoffDecay(maxAge time.Duration) DecayFunction {
+	return &CutoffDecay{maxAge: maxAge}
+}
+
+func (d *CutoffDecay) Name() config.DecayType {
+	return config.CutoffDecay
+}
+
+func (d *CutoffDecay) Factor(age time.Duration) float64 {
+	if age > d.maxAge {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,68 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package decay
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+func TestDecayFactor(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name     string
+		cfg      string
+		age      time.Duration
+		expected float64
+	}{
+		{"none", `{}`, 365 * day, 1},
+		{"linear fresh", `{"type":"linear","config":{"maxAge":864000000}}`, 0, 1},
+		{"linear halfway", `{"type":"linear","config":{"maxAge":864000000}}`, 5 * day, 0.5},
+		{"linear expired", `{"type":"linear","config":{"maxAge":864000000}}`, 11 * day, 0},
+		{"exponential one half-life", `{"type":"exponential","config":{"halfLife":86400000}}`, day, 0.5},
+		{"exponential two half-lives", `{"type":"exponential","config":{"halfLife":86400000}}`, 2 * day, 0.25},
+		{"cutoff within", `{"type":"cutoff","config":{"maxAge":86400000}}`, day, 1},
+		{"cutoff beyond", `{"type":"cutoff","config":{"maxAge":86400000}}`, day + time.Second, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := config.DecayInfo{}
+			if err := json.Unmarshal([]byte(tt.cfg), &info); err != nil {
+				t.Fatal(err)
+			}
+			fn, err := NewDecayFunction(info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := fn.Factor(tt.age)
+			if math.Abs(result-tt.expected) > 0.000001 {
+				t.Errorf("expected factor %v, received %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDecayConfigRequiresAge(t *testing.T) {
+	for _, cfg := range []string{`{"type":"linear"}`, `{"type":"exponential"}`, `{"type":"cutoff"}`} {
+		info := config.DecayInfo{}
+		if err := json.Unmarshal([]byte(cfg), &info); err == nil {
+			t.Errorf("expected an error for %s", cfg)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package decay
+
+import (
+	"math"
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// ExponentialDecay halves confidence every halfLife. Old scores lose influence quickly but never reach zero.
+type ExponentialDecay struct {
+	halfLife time.Duration
+}
+
+func NewExponentialDecay(halfLife time.Duration) DecayFunction {
+	return &ExponentialDecay{halfLife: halfLife}
+}
+
+func (d *ExponentialDecay) Name() config.DecayType {
+	return config.ExponentialDecay
+}
+
+func (d *ExponentialDecay) Factor(age time.Duration) float64 {
+	if age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(d.halfLife))
+}
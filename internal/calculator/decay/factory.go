@@ -0,0 +1,50 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package decay
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+func NewDecayFunction(info config.DecayInfo) (DecayFunction, error) {
+	switch info.Type {
+	case config.NoDecay, "":
+		return NewNoDecay(), nil
+	case config.LinearDecay:
+		cfg, ok := info.Config.(config.LinearDecayConfig)
+		if !ok {
+			return nil, errors.New("invalid cast for linear decay config")
+		}
+		return NewLinearDecay(time.Duration(cfg.MaxAge) * time.Millisecond), nil
+	case config.ExponentialDecay:
+		cfg, ok := info.Config.(config.ExponentialDecayConfig)
+		if !ok {
+			return nil, errors.New("invalid cast for exponential decay config")
+		}
+		return NewExponentialDecay(time.Duration(cfg.HalfLife) * time.Millisecond), nil
+	case config.CutoffDecay:
+		cfg, ok := info.Config.(config.CutoffDecayConfig)
+		if !ok {
+			return nil, errors.New("invalid cast for cutoff decay config")
+		}
+		return NewCutoffDecay(time.Duration(cfg.MaxAge) * time.Millisecond), nil
+	default:
+		return nil, fmt.Errorf("unrecognized decay function %s", info.Type)
+	}
+}
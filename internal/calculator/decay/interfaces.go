@@ -0,0 +1,29 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package decay
+
+import (
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// DecayFunction reduces the confidence of a lower layer score according to how long ago it was calculated.
+type DecayFunction interface {
+	// Name identifies the decay function recorded in the score explanation.
+	Name() config.DecayType
+	// Factor returns the multiplier between 0 and 1 applied to a confidence of the given age.
+	Factor(age time.Duration) float64
+}
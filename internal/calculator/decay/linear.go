@@ -0,0 +1,44 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package decay
+
+import (
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// LinearDecay reduces confidence in a straight line from its full value when calculated to zero at maxAge.
+type LinearDecay struct {
+	maxAge time.Duration
+}
+
+func NewLinearDecay(maxAge time.Duration) DecayFunction {
+	return &LinearDecay{maxAge: maxAge}
+}
+
+func (d *LinearDecay) Name() config.DecayType {
+	return config.LinearDecay
+}
+
+func (d *LinearDecay) Factor(age time.Duration) float64 {
+	if age <= 0 {
+		return 1
+	}
+	if age >= d.maxAge {
+		return 0
+	}
+	return 1 - float64(age)/float64(d.maxAge)
+}
@@ -0,0 +1,36 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package decay
+
+import (
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// NoDecay leaves confidence untouched regardless of age.
+type NoDecay struct{}
+
+func NewNoDecay() DecayFunction {
+	return &NoDecay{}
+}
+
+func (d *NoDecay) Name() config.DecayType {
+	return config.NoDecay
+}
+
+func (d *NoDecay) Factor(age time.Duration) float64 {
+	return 1
+}
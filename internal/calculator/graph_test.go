@@ -0,0 +1,291 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	sdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/factories"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/db"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// fakeGraph keeps the documents of the graph in memory, standing in for ArangoClient.
+type fakeGraph struct {
+	annotations map[string][]documents.Annotation // annotations are keyed by dataRef
+	data        map[string]time.Time              // data holds the timestamp of each data vertex
+	failing     map[string]error                  // failing holds the errors returned when looking up scores by tag
+	lineage     map[string][]string               // lineage holds the parents of each dataRef
+	policies    map[string]documents.Policy
+	scores      []documents.Score
+	stack       map[ulid.ULID][]ulid.ULID // stack links each lower layer score to the scores built on it
+	weighting   map[ulid.ULID]string      // weighting holds the policy key of each score
+	queries     int                       // queries counts the annotation queries made
+	mutex       sync.Mutex
+}
+
+func newFakeGraph() *fakeGraph {
+	return &fakeGraph{
+		annotations: make(map[string][]documents.Annotation),
+		data:        make(map[string]time.Time),
+		failing:     make(map[string]error),
+		lineage:     make(map[string][]string),
+		policies:    make(map[string]documents.Policy),
+		stack:       make(map[ulid.ULID][]ulid.ULID),
+		weighting:   make(map[ulid.ULID]string),
+	}
+}
+
+func (g *fakeGraph) QueryAnnotationsByKeys(ctx context.Context, keys []string) (map[string][]documents.Annotation, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.queries++
+
+	result := make(map[string][]documents.Annotation)
+	for _, k := range keys {
+		if a, ok := g.annotations[k]; ok {
+			result[k] = a
+		}
+	}
+	return result, nil
+}
+
+func (g *fakeGraph) QueryAnnotations(ctx context.Context, key string) ([]documents.Annotation, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.queries++
+
+	return g.annotations[key], nil
+}
+
+func (g *fakeGraph) QueryScoreByTag(
+	ctx context.Context,
+	tag string,
+	layer contracts.LayerType,
+	policy string,
+) (documents.Score, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if err, ok := g.failing[tag]; ok {
+		return documents.Score{}, err
+	}
+	for i := len(g.scores) - 1; i >= 0; i-- {
+		s := g.scores[i]
+		if s.Current && s.Layer == layer && s.Policy == policy && slices.Contains(s.Tag, tag) {
+			return s, nil
+		}
+	}
+	return documents.Score{}, fmt.Errorf("%w for tag: %s", db.ErrScoreNotFound, tag)
+}
+
+func (g *fakeGraph) QueryDependents(ctx context.Context, score documents.Score, limit int) ([]string, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var dataRefs []string
+	for _, lower := range g.scores {
+		if lower.Layer != score.Layer || lower.Policy != score.Policy || lower.Key == score.Key {
+			continue
+		}
+		if !slices.ContainsFunc(lower.Tag, func(t string) bool { return slices.Contains(score.Tag, t) }) {
+			continue
+		}
+		for _, upper := range g.stack[lower.Key] {
+			s := g.score(upper)
+			if s.Current && !slices.Contains(dataRefs, s.DataRef) && len(dataRefs) < limit {
+				dataRefs = append(dataRefs, s.DataRef)
+			}
+		}
+	}
+	return dataRefs, nil
+}
+
+func (g *fakeGraph) QueryParentScores(
+	ctx context.Context,
+	dataRef string,
+	layer contracts.LayerType,
+	policy string,
+) ([]documents.Score, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var parents []documents.Score
+	for _, parent := range g.lineage[dataRef] {
+		latest := documents.Score{DataRef: parent}
+		for i := len(g.scores) - 1; i >= 0; i-- {
+			s := g.scores[i]
+			if s.Current && s.DataRef == parent && s.Layer == layer && s.Policy == policy {
+				latest = s
+				break
+			}
+		}
+		parents = append(parents, latest)
+	}
+	return parents, nil
+}
+
+func (g *fakeGraph) QueryDerived(ctx context.Context, dataRef string, limit int) ([]string, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var dataRefs []string
+	for child, parents := range g.lineage {
+		if slices.Contains(parents, dataRef) && len(dataRefs) < limit {
+			dataRefs = append(dataRefs, child)
+		}
+	}
+	slices.Sort(dataRefs)
+	return dataRefs, nil
+}
+
+func (g *fakeGraph) QueryUnscoredData(
+	ctx context.Context,
+	filter types.BackfillFilter,
+	policies []string,
+) ([]string, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var keys []string
+	for key, timestamp := range g.data {
+		if !filter.From.IsZero() && timestamp.Before(filter.From) || !filter.To.IsZero() && timestamp.After(filter.To) {
+			continue
+		}
+		if filter.Host != "" && !slices.ContainsFunc(g.annotations[key], func(a documents.Annotation) bool {
+			return a.Host == filter.Host
+		}) {
+			continue
+		}
+		scored := 0
+		for _, p := range policies {
+			if slices.ContainsFunc(g.scores, func(s documents.Score) bool {
+				return s.DataRef == key && s.Policy == p && s.Current
+			}) {
+				scored++
+			}
+		}
+		if scored == len(policies) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int { return g.data[a].Compare(g.data[b]) })
+	return keys, nil
+}
+
+func (g *fakeGraph) CreatePolicy(ctx context.Context, policy documents.Policy) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if existing, ok := g.policies[policy.Key]; ok {
+		existing.Activated = policy.Activated
+		policy = existing
+	}
+	g.policies[policy.Key] = policy
+	return nil
+}
+
+func (g *fakeGraph) CreateScore(ctx context.Context, score documents.Score, policyKey string) (documents.Score, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var previous []documents.Score
+	for i := len(g.scores) - 1; i >= 0; i-- {
+		if g.scores[i].DataRef == score.DataRef && g.scores[i].Policy == score.Policy {
+			previous = append(previous, g.scores[i])
+		}
+	}
+	score, superseded := nextVersion(score, previous)
+	for _, p := range superseded {
+		for i := range g.scores {
+			if g.scores[i].Key == p.Key {
+				g.scores[i].Current = false
+			}
+		}
+	}
+	g.scores = append(g.scores, score)
+	g.weighting[score.Key] = policyKey
+	return score, nil
+}
+
+func (g *fakeGraph) CreateEdge(ctx context.Context, src string, target string, collectionName string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if collectionName != documents.EdgeStack {
+		return nil
+	}
+	lower, err := ulid.Parse(src)
+	if err != nil {
+		return err
+	}
+	upper, err := ulid.Parse(target)
+	if err != nil {
+		return err
+	}
+	g.stack[lower] = append(g.stack[lower], upper)
+	return nil
+}
+
+// addScore stores a score as if it had been calculated earlier and returns it.
+func (g *fakeGraph) addScore(dataRef string, layer contracts.LayerType, policy string, confidence float64, tags ...string) documents.Score {
+	score, _ := g.CreateScore(context.Background(), documents.Score{
+		Key:        documents.NewULID(),
+		DataRef:    dataRef,
+		Layer:      layer,
+		Policy:     policy,
+		Confidence: confidence,
+		Tag:        tags,
+	}, "")
+	return score
+}
+
+// current returns the current scores of the dataRef.
+func (g *fakeGraph) current(dataRef string) []documents.Score {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var found []documents.Score
+	for _, s := range g.scores {
+		if s.DataRef == dataRef && s.Current {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+func (g *fakeGraph) score(key ulid.ULID) documents.Score {
+	for _, s := range g.scores {
+		if s.Key == key {
+			return s
+		}
+	}
+	return documents.Score{}
+}
+
+func newTestLogger() interfaces.Logger {
+	return factories.NewLogger(sdkConfig.LoggingInfo{MinLogLevel: slog.LevelError})
+}
@@ -0,0 +1,50 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"context"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// GraphClient is the access to the graph database needed to collect and score keys. It is implemented by ArangoClient.
+type GraphClient interface {
+	// QueryAnnotationsByKeys returns the annotations of each of the supplied keys in a single query. Only the dataRef,
+	// layer and kind of each annotation are read.
+	QueryAnnotationsByKeys(ctx context.Context, keys []string) (map[string][]documents.Annotation, error)
+	// QueryAnnotations returns the annotations of a key.
+	QueryAnnotations(ctx context.Context, key string) ([]documents.Annotation, error)
+	// QueryScoreByTag returns the current score of the layer for the tag under the policy, or ErrScoreNotFound.
+	QueryScoreByTag(ctx context.Context, tag string, layer contracts.LayerType, policy string) (documents.Score, error)
+	// QueryDependents returns the dataRefs of the current scores built on an earlier score like the supplied one.
+	QueryDependents(ctx context.Context, score documents.Score, limit int) ([]string, error)
+	// QueryParentScores returns the current scores of the data the dataRef was mutated from. A parent that has not been
+	// scored is returned as a score without a key.
+	QueryParentScores(ctx context.Context, dataRef string, layer contracts.LayerType, policy string) ([]documents.Score, error)
+	// QueryDerived returns the dataRefs of the data mutated from the supplied dataRef.
+	QueryDerived(ctx context.Context, dataRef string, limit int) ([]string, error)
+	// QueryUnscoredData returns the keys of the data matching the filter that lacks a current score under any of the
+	// policies, oldest first.
+	QueryUnscoredData(ctx context.Context, filter types.BackfillFilter, policies []string) ([]string, error)
+	// CreatePolicy stores a version of a policy.
+	CreatePolicy(ctx context.Context, policy documents.Policy) error
+	// CreateScore persists a new current version of a score, linked to the version of the policy that produced it.
+	CreateScore(ctx context.Context, score documents.Score, policyKey string) (documents.Score, error)
+	// CreateEdge links two documents by an edge in the named collection.
+	CreateEdge(ctx context.Context, src string, target string, collectionName string) error
+}
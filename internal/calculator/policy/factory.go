@@ -46,3 +46,15 @@ func NewPolicyProvider(policyInfo config.PolicyInfo, logger interfaces.Logger) (
 	}
 
 }
+
+// NewDecisionProvider returns the provider of per score decisions. Only OPA policies with a decision path can decide.
+func NewDecisionProvider(policyInfo config.PolicyInfo) (DecisionProvider, error) {
+	if !policyInfo.Decides() {
+		return nil, fmt.Errorf("policy type %s has no decision path configured", policyInfo.Type)
+	}
+	cfg, ok := policyInfo.Config.(config.OpenPolicyConfig)
+	if !ok {
+		return nil, errors.New("invalid cast type for OpenPolicyConfig")
+	}
+	return NewOpenPolicyProvider(cfg), nil
+}
@@ -15,9 +15,27 @@
 package policy
 
 import (
+	"context"
+
 	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+	"github.com/project-alvarium/scoring-apps-go/pkg/requests"
 )
 
 type PolicyProvider interface {
 	GetWeights(classifier string) ([]policies.Weight, error)
+	// GetPolicy returns the whole policy for the classifier, including its weights and confidence bands.
+	GetPolicy(classifier string) (policies.DcfPolicy, error)
+}
+
+// DecisionProvider decides how a piece of data is scored given the full context of its annotations, such as the
+// layer, hosts, tags and the lower layer scores they build on.
+type DecisionProvider interface {
+	Decide(ctx context.Context, input requests.OpaDecisionInput) (Decision, error)
+}
+
+// Decision carries either the weights to score with or the confidence itself. If neither is set, the weights of the
+// policy are used.
+type Decision struct {
+	Weights    []policies.Weight
+	Confidence *float64
 }
@@ -34,6 +34,15 @@ func (lp *LocalPolicyProvider) GetWeights(classifier string) ([]policies.Weight,
 	return nil, fmt.Errorf("Classifier not defined %s", classifier)
 }
 
+func (lp *LocalPolicyProvider) GetPolicy(classifier string) (policies.DcfPolicy, error) {
+	for _, p := range lp.Weights {
+		if p.Name == classifier {
+			return p, nil
+		}
+	}
+	return policies.DcfPolicy{}, fmt.Errorf("Classifier not defined %s", classifier)
+}
+
 func NewLocalPolicyProvider(cfg config.LocalPolicyConfig) PolicyProvider {
 
 	localPolicyProvider := LocalPolicyProvider{}
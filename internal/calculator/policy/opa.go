@@ -15,63 +15,108 @@
 package policy
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
 	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
 	"github.com/project-alvarium/scoring-apps-go/pkg/requests"
 	"github.com/project-alvarium/scoring-apps-go/pkg/responses"
-	"io/ioutil"
-	"net/http"
 )
 
 type OpenPolicyProvider struct {
-	cfg config.OpenPolicyConfig
+	cfg    config.OpenPolicyConfig
+	client *opaClient
 }
 
-func NewOpenPolicyProvider(cfg config.OpenPolicyConfig) PolicyProvider {
+func NewOpenPolicyProvider(cfg config.OpenPolicyConfig) *OpenPolicyProvider {
 	p := OpenPolicyProvider{}
 	p.cfg = cfg
+	p.client = newOpaClient(cfg)
 	return &p
 }
 
 func (p *OpenPolicyProvider) GetWeights(classifier string) ([]policies.Weight, error) {
-
-	// Send request
 	url := p.cfg.Provider.Uri() + p.cfg.WeightsInfo.Path
 	request := requests.OpaWeightsRequest{Classifier: classifier}
-	b, err := json.Marshal(&request)
 
+	// Unmarshal body and convert it to dcf weight array. Weights are not cached, so a reload always sees the current ones.
+	var response responses.OpaWeightsResponse
+	err := p.client.post(context.Background(), url, "", &request, &response)
 	if err != nil {
 		return nil, err
 	}
-	result, err := http.Post(
-		url,
-		"application/json",
-		bytes.NewBuffer(b),
-	)
+	return toWeights(response.Weights), nil
+}
+
+// GetPolicy fetches the weights of the classifier and, if a bands path is configured, its confidence bands.
+func (p *OpenPolicyProvider) GetPolicy(classifier string) (policies.DcfPolicy, error) {
+	weights, err := p.GetWeights(classifier)
 	if err != nil {
-		return nil, err
+		return policies.DcfPolicy{}, err
+	}
+	dcfPolicy := policies.DcfPolicy{Name: classifier, Weights: weights}
+	if p.cfg.BandsInfo.Path == "" {
+		return dcfPolicy, nil
 	}
 
-	// Read body
-	b, err = ioutil.ReadAll(result.Body)
+	url := p.cfg.Provider.Uri() + p.cfg.BandsInfo.Path
+	request := requests.OpaWeightsRequest{Classifier: classifier}
+	var response responses.OpaBandsResponse
+	err = p.client.post(context.Background(), url, "", &request, &response)
 	if err != nil {
-		return nil, err
+		return policies.DcfPolicy{}, err
 	}
-	result.Body.Close()
-	// Unmarshal body and convert it to dcf weight array
-	var response responses.OpaWeightsResponse
-	err = json.Unmarshal(b, &response)
+	dcfPolicy.Bands = response.Bands
+	return dcfPolicy, nil
+}
+
+// Decide queries the decision rule with the context of a score. The weights of the decision are sorted by annotation
+// key.
+func (p *OpenPolicyProvider) Decide(ctx context.Context, input requests.OpaDecisionInput) (Decision, error) {
+	url := p.cfg.Provider.Uri() + p.cfg.DecisionInfo.Path
+	request := requests.OpaDecisionRequest{Input: input}
+
+	var response responses.OpaDecisionResponse
+	err := p.client.post(ctx, url, decisionCacheKey(input), &request, &response)
 	if err != nil {
-		return nil, err
+		return Decision{}, err
+	}
+
+	c := response.Result.Confidence
+	if c != nil && (*c < 0 || *c > 1) {
+		return Decision{}, fmt.Errorf("decided confidence %v for %s is not between 0 and 1", *c, input.DataRef)
 	}
+	return Decision{Weights: toWeights(response.Result.Weights), Confidence: c}, nil
+}
+
+// decisionCacheKey identifies the context of a decision by the whole input, since the decision rule can read any of it,
+// including the dataRef and timestamps. The hosts, tags and annotations are sorted so that the same context always has
+// the same key, whatever order the annotations were found in.
+func decisionCacheKey(input requests.OpaDecisionInput) string {
+	input.Hosts = slices.Clone(input.Hosts)
+	input.Tags = slices.Clone(input.Tags)
+	input.Annotations = slices.Clone(input.Annotations)
+	slices.Sort(input.Hosts)
+	slices.Sort(input.Tags)
+	slices.SortFunc(input.Annotations, func(a, b documents.Annotation) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	b, _ := json.Marshal(input)
+	return string(b)
+}
+
+func toWeights(values responses.OpaWeights) []policies.Weight {
 	var weights []policies.Weight
-	for k, v := range response.Weights {
-		weight := policies.Weight{}
-		weight.AnnotationKey = k
-		weight.Value = v
-		weights = append(weights, weight)
+	for _, v := range values {
+		weights = append(weights, v)
 	}
-	return weights, nil
+	slices.SortFunc(weights, func(a, b policies.Weight) int {
+		return strings.Compare(a.AnnotationKey, b.AnnotationKey)
+	})
+	return weights
 }
@@ -0,0 +1,198 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+const (
+	defaultOpaTimeout    = 5 * time.Second
+	defaultOpaRetryDelay = 500 * time.Millisecond
+)
+
+// opaClient posts queries to OPA with a deadline on every attempt, retrying attempts that failed in transport or on the
+// server and caching successful responses by the cache key supplied with each request.
+type opaClient struct {
+	cache      map[string]cachedResponse
+	cacheTTL   time.Duration
+	client     *http.Client
+	mutex      sync.Mutex
+	retries    int
+	retryDelay time.Duration
+	swept      time.Time
+}
+
+type cachedResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+func newOpaClient(cfg config.OpenPolicyConfig) *opaClient {
+	timeout := defaultOpaTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Millisecond
+	}
+	retryDelay := defaultOpaRetryDelay
+	if cfg.RetryDelay > 0 {
+		retryDelay = time.Duration(cfg.RetryDelay) * time.Millisecond
+	}
+	return &opaClient{
+		cache:      make(map[string]cachedResponse),
+		cacheTTL:   time.Duration(cfg.CacheTTL) * time.Millisecond,
+		client:     &http.Client{Timeout: timeout},
+		retries:    cfg.Retries,
+		retryDelay: retryDelay,
+		swept:      time.Now(),
+	}
+}
+
+// statusError is returned when OPA answers with a status other than 200 OK.
+type statusError struct {
+	status string
+	code   int
+}
+
+func (e statusError) Error() string {
+	return "OPA responded with " + e.status
+}
+
+// retryable reports whether a failed attempt may succeed if it is made again. Transport errors and server errors are
+// retried, while client errors such as a malformed request or an unknown path are not.
+func retryable(err error) bool {
+	var se statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// post sends the request to the url and unmarshals the response body into the response. Responses are cached under the
+// url and the cache key, which must hold every part of the request that the answer can depend on. Responses to requests
+// without a cache key are not cached.
+func (c *opaClient) post(ctx context.Context, url string, cacheKey string, request interface{}, response interface{}) error {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	if cacheKey == "" {
+		body, err := c.send(ctx, url, b)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(body, response)
+	}
+
+	cacheKey = url + "\n" + cacheKey
+	body, ok := c.cached(cacheKey)
+	if !ok {
+		body, err = c.send(ctx, url, b)
+		if err != nil {
+			return err
+		}
+		c.store(cacheKey, body)
+	}
+	return json.Unmarshal(body, response)
+}
+
+// send makes up to retries + 1 attempts to post the body, waiting the retry delay between them. It gives up at once on
+// an error that is not retryable.
+func (c *opaClient) send(ctx context.Context, url string, b []byte) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.retryDelay):
+			}
+		}
+
+		var body []byte
+		body, err = c.attempt(ctx, url, b)
+		if err == nil {
+			return body, nil
+		}
+		if !retryable(err) {
+			return nil, fmt.Errorf("OPA request to %s failed: %w", url, err)
+		}
+	}
+	return nil, fmt.Errorf("OPA request to %s failed after %v attempts: %w", url, c.retries+1, err)
+}
+
+func (c *opaClient) attempt(ctx context.Context, url string, b []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	result, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode != http.StatusOK {
+		return nil, statusError{status: result.Status, code: result.StatusCode}
+	}
+	return io.ReadAll(result.Body)
+}
+
+func (c *opaClient) cached(key string) ([]byte, bool) {
+	if c.cacheTTL <= 0 {
+		return nil, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	r, ok := c.cache[key]
+	if !ok || time.Now().After(r.expires) {
+		return nil, false
+	}
+	return r.body, true
+}
+
+// store caches the response body. Expired responses are swept out at most once per cache lifetime.
+func (c *opaClient) store(key string, body []byte) {
+	if c.cacheTTL <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.swept) > c.cacheTTL {
+		for k, r := range c.cache {
+			if now.After(r.expires) {
+				delete(c.cache, k)
+			}
+		}
+		c.swept = now
+	}
+	c.cache[key] = cachedResponse{body: body, expires: now.Add(c.cacheTTL)}
+}
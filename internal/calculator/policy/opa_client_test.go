@@ -0,0 +1,137 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package policy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/requests"
+	"github.com/project-alvarium/scoring-apps-go/pkg/responses"
+)
+
+func TestOpaClientPost(t *testing.T) {
+	tests := []struct {
+		name             string
+		cfg              config.OpenPolicyConfig
+		cacheKey         string
+		status           int
+		failures         int
+		expectError      bool
+		expectedRequests int
+	}{
+		{"no retries", config.OpenPolicyConfig{}, "key", http.StatusServiceUnavailable, 2, true, 2},
+		{"retried", config.OpenPolicyConfig{Retries: 2, RetryDelay: 1}, "key", http.StatusServiceUnavailable, 2, false, 4},
+		{"client error", config.OpenPolicyConfig{Retries: 2, RetryDelay: 1}, "key", http.StatusBadRequest, 2, true, 2},
+		{"cached", config.OpenPolicyConfig{CacheTTL: 60000}, "key", http.StatusOK, 0, false, 1},
+		{"no cache key", config.OpenPolicyConfig{CacheTTL: 60000}, "", http.StatusOK, 0, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received++
+				if received <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Write([]byte(`{"result":{"confidence":0.5}}`))
+			}))
+			defer server.Close()
+
+			client := newOpaClient(tt.cfg)
+			request := requests.OpaDecisionRequest{Input: requests.OpaDecisionInput{DataRef: "key"}}
+			var err error
+			for i := 0; i < 2; i++ {
+				var response responses.OpaDecisionResponse
+				err = client.post(context.Background(), server.URL, tt.cacheKey, &request, &response)
+				if err == nil && *response.Result.Confidence != 0.5 {
+					t.Errorf("expected confidence 0.5, received %v", *response.Result.Confidence)
+				}
+			}
+			if tt.expectError && err == nil {
+				t.Error("expected an error")
+			} else if !tt.expectError && err != nil {
+				t.Fatal(err)
+			}
+			if received != tt.expectedRequests {
+				t.Errorf("expected %v requests, received %v", tt.expectedRequests, received)
+			}
+		})
+	}
+}
+
+func TestDecisionCacheKey(t *testing.T) {
+	valid := true
+	input := requests.OpaDecisionInput{
+		Classifier: "default",
+		DataRef:    "data-1",
+		Layer:      contracts.Application,
+		Hosts:      []string{"host-a", "host-b"},
+		Tags:       []string{"tag-a"},
+		Annotations: []documents.Annotation{
+			{Key: "a1", DataRef: "data-1", Kind: "tpm", Host: "host-a", IsSatisfied: true, Timestamp: time.Now()},
+			{Key: "a2", DataRef: "data-1", Kind: "tls", Host: "host-b", IsSatisfied: false, Timestamp: time.Now()},
+		},
+		LowerLayers: []requests.OpaLowerLayerScore{
+			{Layer: contracts.Os, JoinOn: "host", Field: "host-a", ScoreKey: "s1", Confidence: 0.8, Timestamp: time.Now()},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		change   func(in *requests.OpaDecisionInput)
+		expected bool // expected is true if the changed input should share the cache key
+	}{
+		{"reordered annotations", func(in *requests.OpaDecisionInput) {
+			in.Annotations[0], in.Annotations[1] = in.Annotations[1], in.Annotations[0]
+		}, true},
+		{"reordered hosts", func(in *requests.OpaDecisionInput) { in.Hosts = []string{"host-b", "host-a"} }, true},
+		// Everything the decision rule can read is part of the key, including what differs for every piece of data
+		{"other data", func(in *requests.OpaDecisionInput) { in.DataRef = "data-2" }, false},
+		{"annotation timestamp", func(in *requests.OpaDecisionInput) {
+			in.Annotations[0].Timestamp = in.Annotations[0].Timestamp.Add(time.Minute)
+		}, false},
+		{"newer lower layer score", func(in *requests.OpaDecisionInput) {
+			in.LowerLayers[0].ScoreKey = "s2"
+			in.LowerLayers[0].Timestamp = in.LowerLayers[0].Timestamp.Add(time.Minute)
+		}, false},
+		{"classifier", func(in *requests.OpaDecisionInput) { in.Classifier = "strict" }, false},
+		{"satisfied", func(in *requests.OpaDecisionInput) { in.Annotations[1].IsSatisfied = true }, false},
+		{"signature", func(in *requests.OpaDecisionInput) { in.Annotations[0].SignatureValid = &valid }, false},
+		{"host", func(in *requests.OpaDecisionInput) { in.Annotations[0].Host = "host-b" }, false},
+		{"lower layer confidence", func(in *requests.OpaDecisionInput) { in.LowerLayers[0].Confidence = 0.1 }, false},
+		{"stand-in", func(in *requests.OpaDecisionInput) { in.LowerLayers[0].ScoreKey = "" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := input
+			changed.Annotations = slices.Clone(input.Annotations)
+			changed.LowerLayers = slices.Clone(input.LowerLayers)
+			tt.change(&changed)
+
+			same := decisionCacheKey(input) == decisionCacheKey(changed)
+			if same != tt.expected {
+				t.Errorf("expected the cache keys to match to be %v", tt.expected)
+			}
+		})
+	}
+}
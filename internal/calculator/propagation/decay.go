@@ -0,0 +1,41 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package propagation
+
+import (
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// DecayPropagation limits the confidence of mutated data to that of its least trusted parent multiplied by factor.
+// Since each parent was limited in the same way, data n mutations away from a fully trusted source can reach at most
+// factor to the power of n.
+type DecayPropagation struct {
+	factor float64
+}
+
+func NewDecayPropagation(factor float64) PropagationFunction {
+	return &DecayPropagation{factor: factor}
+}
+
+func (p *DecayPropagation) Name() config.PropagationType {
+	return config.DecayPropagation
+}
+
+func (p *DecayPropagation) Propagate(confidence float64, parents []float64) float64 {
+	for _, c := range parents {
+		confidence = min(confidence, c*p.factor)
+	}
+	return confidence
+}
@@ -0,0 +1,40 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package propagation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+func NewPropagationFunction(info config.PropagationInfo) (PropagationFunction, error) {
+	switch info.Type {
+	case config.NoPropagation, "":
+		return NewNoPropagation(), nil
+	case config.MinimumPropagation:
+		return NewMinimumPropagation(), nil
+	case config.ProductPropagation:
+		return NewProductPropagation(), nil
+	case config.DecayPropagation:
+		cfg, ok := info.Config.(config.DecayPropagationConfig)
+		if !ok {
+			return nil, errors.New("invalid cast for decay propagation config")
+		}
+		return NewDecayPropagation(cfg.Factor), nil
+	}
+	return nil, fmt.Errorf("unsupported propagation type %s", info.Type)
+}
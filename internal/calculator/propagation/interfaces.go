@@ -0,0 +1,28 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package propagation
+
+import (
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// PropagationFunction folds the confidence of the parent data a piece of data was mutated from into its own. The
+// parent scores are themselves folded with their own parents, so trust propagates along the whole lineage.
+type PropagationFunction interface {
+	// Name identifies the propagation function recorded in the score explanation.
+	Name() config.PropagationType
+	// Propagate returns the confidence of the data given its own confidence and that of each of its parents.
+	Propagate(confidence float64, parents []float64) float64
+}
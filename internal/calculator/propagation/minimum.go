@@ -0,0 +1,38 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package propagation
+
+import (
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// MinimumPropagation limits the confidence of mutated data to that of its least trusted parent. A mutation can keep
+// the trust of its source but never add to it.
+type MinimumPropagation struct{}
+
+func NewMinimumPropagation() PropagationFunction {
+	return &MinimumPropagation{}
+}
+
+func (p *MinimumPropagation) Name() config.PropagationType {
+	return config.MinimumPropagation
+}
+
+func (p *MinimumPropagation) Propagate(confidence float64, parents []float64) float64 {
+	for _, c := range parents {
+		confidence = min(confidence, c)
+	}
+	return confidence
+}
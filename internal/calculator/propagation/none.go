@@ -0,0 +1,34 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package propagation
+
+import (
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// NoPropagation ignores lineage, so mutated data is scored on its own annotations alone.
+type NoPropagation struct{}
+
+func NewNoPropagation() PropagationFunction {
+	return &NoPropagation{}
+}
+
+func (p *NoPropagation) Name() config.PropagationType {
+	return config.NoPropagation
+}
+
+func (p *NoPropagation) Propagate(confidence float64, parents []float64) float64 {
+	return confidence
+}
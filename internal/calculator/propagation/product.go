@@ -0,0 +1,38 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package propagation
+
+import (
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// ProductPropagation multiplies the confidence of mutated data by that of each parent, so doubt about the data and
+// its sources compounds.
+type ProductPropagation struct{}
+
+func NewProductPropagation() PropagationFunction {
+	return &ProductPropagation{}
+}
+
+func (p *ProductPropagation) Name() config.PropagationType {
+	return config.ProductPropagation
+}
+
+func (p *ProductPropagation) Propagate(confidence float64, parents []float64) float64 {
+	for _, c := range parents {
+		confidence *= c
+	}
+	return confidence
+}
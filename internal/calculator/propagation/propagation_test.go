@@ -0,0 +1,67 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package propagation
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+func TestPropagate(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        string
+		confidence float64
+		parents    []float64
+		expected   float64
+	}{
+		{"none", `{}`, 1, []float64{0.2}, 1},
+		{"min", `{"type":"min"}`, 1, []float64{0.6, 0.4}, 0.4},
+		{"min own lower", `{"type":"min"}`, 0.3, []float64{0.6}, 0.3},
+		{"min no parents", `{"type":"min"}`, 0.7, nil, 0.7},
+		{"product", `{"type":"product"}`, 0.8, []float64{0.5, 0.5}, 0.2},
+		{"decay", `{"type":"decay","config":{"factor":0.9}}`, 1, []float64{1}, 0.9},
+		{"decay own lower", `{"type":"decay","config":{"factor":0.9}}`, 0.5, []float64{1}, 0.5},
+		{"decay untrusted parent", `{"type":"decay","config":{"factor":0.9}}`, 1, []float64{0.2}, 0.18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := config.PropagationInfo{}
+			if err := json.Unmarshal([]byte(tt.cfg), &info); err != nil {
+				t.Fatal(err)
+			}
+			fn, err := NewPropagationFunction(info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := fn.Propagate(tt.confidence, tt.parents)
+			if math.Abs(result-tt.expected) > 0.000001 {
+				t.Errorf("expected confidence %v, received %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestPropagationConfigRequiresFactor(t *testing.T) {
+	for _, cfg := range []string{`{"type":"decay"}`, `{"type":"decay","config":{"factor":1.5}}`, `{"type":"sum"}`} {
+		info := config.PropagationInfo{}
+		if err := json.Unmarshal([]byte(cfg), &info); err == nil {
+			t.Errorf("expected an error for %s", cfg)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	SdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	SdkInterfaces "github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/factories"
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/msg"
+)
+
+// Publisher notifies other applications of every score persisted by the calculator, so they do not have to poll the
+// database for new scores.
+type Publisher struct {
+	instance interfaces.Publisher
+	logger   SdkInterfaces.Logger
+}
+
+func NewPublisher(endpoint SdkConfig.StreamInfo, logger SdkInterfaces.Logger) (*Publisher, error) {
+	t, err := factories.NewPublisher(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{
+		instance: t,
+		logger:   logger,
+	}, nil
+}
+
+// Publish sends a ScoreCalculated event for the persisted score.
+func (p *Publisher) Publish(ctx context.Context, score documents.Score) error {
+	event := msg.ScoreCalculated{
+		DataRef:    score.DataRef,
+		Layer:      score.Layer,
+		Confidence: score.Confidence,
+		Band:       score.Band,
+		Policy:     score.Policy,
+		ScoreKey:   score.Key,
+		Timestamp:  score.Timestamp,
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	err = p.instance.Publish(ctx, msg.PublishWrapper{MessageType: msg.ScoreCalculatedType, Content: b})
+	if err != nil {
+		return err
+	}
+	p.logger.Write(slog.LevelDebug, fmt.Sprintf("%s published %s", msg.ScoreCalculatedType, score.Key.String()))
+	return nil
+}
+
+func (p *Publisher) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bool {
+	wg.Add(1)
+	go func() { // Graceful shutdown
+		defer wg.Done()
+
+		<-ctx.Done()
+		p.instance.Close()
+		p.logger.Write(slog.LevelInfo, "shutdown received")
+	}()
+	return true
+}
@@ -0,0 +1,235 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/policy"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/factories"
+	pubsubInterfaces "github.com/project-alvarium/scoring-apps-go/internal/pubsub/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/pkg/msg"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+// watchInterval is how often the config file is checked for changes when watching local policies.
+const watchInterval = time.Second
+
+// Reloader refreshes the weights of the policies the calculator scores under while it is running. Local policies are
+// read again from the config file on every refresh, OPA policies are requested from the provider again.
+type Reloader struct {
+	classifiers  []string
+	configPath   string
+	expectations *types.Expectations
+	info         config.ReloadInfo
+	logger       interfaces.Logger
+	modified     time.Time
+	mutex        sync.Mutex
+	policies     *types.PolicySet
+	policyType   config.PolicyType
+	provider     policy.PolicyProvider
+	subscriber   pubsubInterfaces.Subscriber
+}
+
+func NewReloader(
+	info config.ReloadInfo,
+	configPath string,
+	policyType config.PolicyType,
+	provider policy.PolicyProvider,
+	classifiers []string,
+	dcfPolicies *types.PolicySet,
+	expectations *types.Expectations,
+	logger interfaces.Logger,
+) (*Reloader, error) {
+	r := Reloader{
+		classifiers:  classifiers,
+		configPath:   configPath,
+		expectations: expectations,
+		info:         info,
+		logger:       logger,
+		policies:     dcfPolicies,
+		policyType:   policyType,
+		provider:     provider,
+	}
+	if info.Control != nil {
+		s, err := factories.NewSubscriber(*info.Control)
+		if err != nil {
+			return nil, err
+		}
+		r.subscriber = s
+	}
+	if info.Watch {
+		stat, err := os.Stat(configPath)
+		if err != nil {
+			return nil, err
+		}
+		r.modified = stat.ModTime()
+	}
+	return &r, nil
+}
+
+// LoadPolicies fetches the policy of every classifier from the provider.
+func LoadPolicies(provider policy.PolicyProvider, classifiers []string) ([]policies.DcfPolicy, error) {
+	var dcfPolicies []policies.DcfPolicy
+	for _, classifier := range classifiers {
+		p, err := provider.GetPolicy(classifier)
+		if err != nil {
+			return nil, err
+		}
+		dcfPolicies = append(dcfPolicies, p)
+	}
+	return dcfPolicies, nil
+}
+
+func (r *Reloader) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bool {
+	if r.info.Interval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.poll(ctx, time.Duration(r.info.Interval)*time.Millisecond, func() bool { return true }, "interval")
+		}()
+	}
+
+	if r.info.Watch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.poll(ctx, watchInterval, r.fileChanged, "config file change")
+		}()
+	}
+
+	if r.subscriber != nil {
+		chErrors := make(chan error)
+		go logErrors(chErrors, r.logger)
+
+		chMessages := make(chan msg.SubscribeWrapper)
+		go r.subscriber.Subscribe(ctx, chMessages, chErrors)
+
+		wg.Add(1)
+		go func() { // Process control messages
+			defer wg.Done()
+
+			for {
+				_, ok := <-chMessages
+				if !ok {
+					return
+				}
+				r.reload("control message")
+			}
+		}()
+
+		wg.Add(1)
+		go func() { // Graceful shutdown
+			defer wg.Done()
+
+			<-ctx.Done()
+			r.subscriber.Close()
+			r.logger.Write(slog.LevelInfo, "shutdown received")
+		}()
+	}
+	return true
+}
+
+// poll reloads the policies every time the interval elapses and the condition holds, until the context is cancelled.
+func (r *Reloader) poll(ctx context.Context, interval time.Duration, condition func() bool, trigger string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if condition() {
+				r.reload(trigger)
+			}
+		}
+	}
+}
+
+// fileChanged reports whether the config file was modified since it was last checked.
+func (r *Reloader) fileChanged() bool {
+	stat, err := os.Stat(r.configPath)
+	if err != nil {
+		r.logger.Error(err.Error())
+		return false
+	}
+	if stat.ModTime().Equal(r.modified) {
+		return false
+	}
+	r.modified = stat.ModTime()
+	return true
+}
+
+// reload fetches the weights of every classifier and makes them active if any have changed. If the weights cannot be
+// fetched for all of them, the active policies are kept.
+func (r *Reloader) reload(trigger string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.policyType == config.LocalPolicy {
+		err := r.readProvider()
+		if err != nil {
+			r.logger.Error(err.Error())
+			return
+		}
+	}
+
+	dcfPolicies, err := LoadPolicies(r.provider, r.classifiers)
+	if err != nil {
+		r.logger.Error(err.Error())
+		return
+	}
+
+	changed := r.policies.Replace(dcfPolicies)
+	if len(changed) == 0 {
+		r.logger.Write(slog.LevelDebug, "policy weights unchanged after "+trigger)
+		return
+	}
+	r.expectations.Reload(dcfPolicies)
+	for _, p := range dcfPolicies {
+		if slices.Contains(changed, p.Name) {
+			r.logger.Write(slog.LevelInfo, fmt.Sprintf("policy %s weights changed after %s: %v", p.Name, trigger, p.Weights))
+		}
+	}
+}
+
+// readProvider reads the policy config from the config file again and replaces the provider with one built from it.
+func (r *Reloader) readProvider() error {
+	reader, err := config.NewReader(config.GetFileExtension(r.configPath))
+	if err != nil {
+		return err
+	}
+	cfg := ApplicationConfig{}
+	err = reader.Read(r.configPath, &cfg)
+	if err != nil {
+		return err
+	}
+	provider, err := policy.NewPolicyProvider(cfg.Policy, r.logger)
+	if err != nil {
+		return err
+	}
+	r.policyType = cfg.Policy.Type
+	r.provider = provider
+	return nil
+}
@@ -0,0 +1,273 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scoring
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/decay"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+// Dependency holds the scores found in a lower layer for the values of the annotation field joining it to the layer
+// being scored, keyed by field value.
+type Dependency struct {
+	Layer  contracts.LayerType
+	JoinOn config.JoinField
+	Scores map[string]documents.Score
+}
+
+// JoinValue returns the field of the annotation used to find its score in a lower layer.
+func JoinValue(a documents.Annotation, join config.JoinField) string {
+	if join == config.JoinHost {
+		return a.Host
+	}
+	return a.Tag
+}
+
+// StandIn returns the score used in place of a missing lower layer score under the configured action. Stand-ins have
+// no key. False is returned if the action does not supply one.
+func StandIn(layer contracts.LayerType, info config.MissingInfo) (documents.Score, bool) {
+	switch info.Action {
+	case config.MultiplyMissing:
+		return documents.Score{Layer: layer, Confidence: info.Multiplier}, true
+	case config.ZeroMissing:
+		return documents.Score{Layer: layer}, true
+	}
+	return documents.Score{}, false
+}
+
+// NewScore calculates the confidence for the supplied annotations with the given strategy and returns the resulting
+// Score document along with an explanation of how it was arrived at. The dependencies hold the scores of the lower
+// layers referenced by the annotations. Their confidence is reduced by the decay function according to their age
+// before it is used. If the policy requires signatures, annotations without a verified signature count as unsatisfied.
+func NewScore(
+	strategy ScoringStrategy,
+	decayFn decay.DecayFunction,
+	dataRef string,
+	annotations []documents.Annotation,
+	policy policies.DcfPolicy,
+	dependencies []Dependency,
+) documents.Score {
+	annotations = screen(annotations, policy)
+	now := time.Now()
+	var layers []lowerLayer
+	var lowerLayers []float64
+	for _, d := range dependencies {
+		l := newLowerLayer(d, annotations, decayFn, now)
+		if l.counts() {
+			lowerLayers = append(lowerLayers, l.decayed)
+		}
+		layers = append(layers, l)
+	}
+	confidence := veto(annotations, policy, strategy.Confidence(annotations, policy, lowerLayers))
+
+	s := documents.NewScore(dataRef, annotations, policy.Name, string(strategy.Name()), confidence)
+	s.Band = policy.Band(s.Confidence)
+	s.Explanation = explain(strategy, annotations, policy, layers)
+	if decayFn != nil {
+		s.Explanation.Decay = string(decayFn.Name())
+	}
+	return s
+}
+
+// screen returns the annotations with those whose signature was not verified marked as unsatisfied, if the policy
+// requires signatures. The supplied annotations are left untouched.
+func screen(annotations []documents.Annotation, policy policies.DcfPolicy) []documents.Annotation {
+	if !policy.RequireSignatures {
+		return annotations
+	}
+	screened := make([]documents.Annotation, len(annotations))
+	for i, a := range annotations {
+		if !a.Verified() {
+			a.IsSatisfied = false
+		}
+		screened[i] = a
+	}
+	return screened
+}
+
+// weigh returns the total weight of the satisfied annotations and the total weight of all annotations.
+func weigh(annotations []documents.Annotation, policy policies.DcfPolicy) (passedWeight float64, totalWeight float64) {
+	for _, a := range annotations {
+		w := policy.FetchWeight(a.Kind, a.Layer, a.Action)
+		totalWeight += w.Value
+		if a.IsSatisfied {
+			passedWeight += w.Value
+		}
+	}
+	return passedWeight, totalWeight
+}
+
+// veto limits the confidence to the cap of every required annotation that was not satisfied, and to the cap of every
+// required kind that was not received at all.
+func veto(annotations []documents.Annotation, policy policies.DcfPolicy, confidence float64) float64 {
+	for _, a := range annotations {
+		w := policy.FetchWeight(a.Kind, a.Layer, a.Action)
+		if w.Required && !a.IsSatisfied {
+			confidence = min(confidence, w.Cap)
+		}
+	}
+	for _, w := range absentRequired(annotations, policy) {
+		confidence = min(confidence, w.Cap)
+	}
+	return confidence
+}
+
+// absentRequired returns the weights of the policy, after any override for the layer and action of the annotations,
+// that are required but have no annotation of their kind among the annotations.
+func absentRequired(annotations []documents.Annotation, policy policies.DcfPolicy) []policies.Weight {
+	if len(annotations) == 0 {
+		return nil
+	}
+	var absent []policies.Weight
+	for _, item := range policy.Weights {
+		received := slices.ContainsFunc(annotations, func(a documents.Annotation) bool {
+			return a.Kind == item.AnnotationKey
+		})
+		if received {
+			continue
+		}
+		w := policy.FetchWeight(item.AnnotationKey, annotations[0].Layer, annotations[0].Action)
+		if w.Required {
+			absent = append(absent, w)
+		}
+	}
+	return absent
+}
+
+// lowerLayer holds the average confidence of a dependency across all annotations before and after decay, along with
+// the decay factor applied to each of its scores. Substituted is set when a stand-in for a missing score was included.
+type lowerLayer struct {
+	dependency  Dependency
+	factors     map[string]float64
+	confidence  float64
+	decayed     float64
+	substituted bool
+}
+
+// newLowerLayer works out the decay factor of each score in the dependency from its age and averages their confidence
+// across all annotations. Scores without a timestamp are not decayed. Scores without a key are stand-ins for missing
+// scores.
+func newLowerLayer(d Dependency, annotations []documents.Annotation, decayFn decay.DecayFunction, now time.Time) lowerLayer {
+	l := lowerLayer{
+		dependency: d,
+		factors:    make(map[string]float64, len(d.Scores)),
+	}
+	for field, s := range d.Scores {
+		l.factors[field] = 1
+		if decayFn != nil && !s.Timestamp.IsZero() {
+			l.factors[field] = decayFn.Factor(now.Sub(s.Timestamp))
+		}
+	}
+
+	for _, a := range annotations {
+		field := JoinValue(a, d.JoinOn)
+		s, exists := d.Scores[field]
+		if !exists {
+			continue
+		}
+		l.confidence += s.Confidence
+		l.decayed += s.Confidence * l.factors[field]
+		if s.Key == (ulid.ULID{}) {
+			l.substituted = true
+		}
+	}
+	l.confidence /= float64(len(annotations))
+	l.decayed /= float64(len(annotations))
+	return l
+}
+
+// counts indicates whether the layer should influence the score. A layer whose average before decay is zero is left
+// out since a layer without a calculated confidence should not influence the score. A layer that has decayed to zero
+// still counts, as does one where a stand-in was used for a missing score.
+func (l lowerLayer) counts() bool {
+	return l.confidence > 0 || l.substituted
+}
+
+// explain records the weight and contribution of every annotation and the lower layer scores that were multiplied in.
+func explain(
+	strategy ScoringStrategy,
+	annotations []documents.Annotation,
+	policy policies.DcfPolicy,
+	layers []lowerLayer,
+) *documents.Explanation {
+	e := documents.Explanation{
+		Policy:   policy.Name,
+		Strategy: string(strategy.Name()),
+	}
+	for _, l := range layers {
+		e.LowerLayers = append(e.LowerLayers, l.explain())
+	}
+
+	_, totalWeight := weigh(annotations, policy)
+	for _, a := range annotations {
+		w := policy.FetchWeight(a.Kind, a.Layer, a.Action)
+		c := documents.AnnotationContribution{
+			Key:         a.Key,
+			Kind:        a.Kind,
+			Action:      a.Action,
+			Weight:      w.Value,
+			Required:    w.Required,
+			Vetoed:      w.Required && !a.IsSatisfied,
+			IsSatisfied: a.IsSatisfied,
+			Unverified:  policy.RequireSignatures && !a.Verified(),
+		}
+		if a.IsSatisfied && totalWeight > 0 {
+			c.Contribution = w.Value / totalWeight
+		}
+		e.Annotations = append(e.Annotations, c)
+	}
+	for _, w := range absentRequired(annotations, policy) {
+		e.AbsentRequired = append(e.AbsentRequired, w.AnnotationKey)
+	}
+	return &e
+}
+
+// explain lists the lower layer scores ordered by field. Stand-ins for missing scores are left out since they are
+// recorded on the score document itself.
+func (l lowerLayer) explain() documents.LowerLayerExplanation {
+	e := documents.LowerLayerExplanation{
+		Layer:   l.dependency.Layer,
+		JoinOn:  string(l.dependency.JoinOn),
+		Counted: l.counts(),
+	}
+	if e.Counted {
+		e.Confidence = l.decayed
+	}
+
+	for field, s := range l.dependency.Scores {
+		if s.Key == (ulid.ULID{}) {
+			continue
+		}
+		e.Scores = append(e.Scores, documents.LowerLayerScore{
+			Field:       field,
+			ScoreKey:    s.Key.String(),
+			Layer:       s.Layer,
+			Confidence:  s.Confidence,
+			DecayFactor: l.factors[field],
+		})
+	}
+	slices.SortFunc(e.Scores, func(a, b documents.LowerLayerScore) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return e
+}
@@ -0,0 +1,62 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scoring
+
+import (
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+const (
+	defaultAlpha float64 = 1
+	defaultBeta  float64 = 1
+)
+
+// BayesianStrategy treats the weighted annotations as observations of a Bernoulli trial and uses the mean of the
+// posterior beta distribution as the confidence. The prior keeps data with very few annotations from scoring 0 or 1
+// outright. The result is then multiplied by the confidence of each lower layer.
+type BayesianStrategy struct {
+	alpha float64
+	beta  float64
+}
+
+func NewBayesianStrategy(cfg config.BayesianConfig) ScoringStrategy {
+	s := BayesianStrategy{
+		alpha: cfg.Alpha,
+		beta:  cfg.Beta,
+	}
+	if s.alpha <= 0 {
+		s.alpha = defaultAlpha
+	}
+	if s.beta <= 0 {
+		s.beta = defaultBeta
+	}
+	return &s
+}
+
+func (s *BayesianStrategy) Name() config.ScoringType {
+	return config.Bayesian
+}
+
+func (s *BayesianStrategy) Confidence(annotations []documents.Annotation, policy policies.DcfPolicy, lowerLayers []float64) float64 {
+	passedWeight, totalWeight := weigh(annotations, policy)
+	confidence := (s.alpha + passedWeight) / (s.alpha + s.beta + totalWeight)
+
+	for _, c := range lowerLayers {
+		confidence *= c
+	}
+	return confidence
+}
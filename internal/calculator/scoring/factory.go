@@ -0,0 +1,45 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scoring
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+func NewScoringStrategy(info config.ScoringInfo) (ScoringStrategy, error) {
+	switch info.Type {
+	case config.WeightedRatio, "":
+		return NewWeightedRatioStrategy(), nil
+	case config.WeightedMinimum:
+		return NewWeightedMinimumStrategy(), nil
+	case config.Bayesian:
+		cfg := config.BayesianConfig{}
+		if info.Config != nil {
+			var ok bool
+			cfg, ok = info.Config.(config.BayesianConfig)
+			if !ok {
+				return nil, errors.New("invalid cast for bayesian scoring config")
+			}
+		}
+		return NewBayesianStrategy(cfg), nil
+	case config.GeometricMean:
+		return NewGeometricMeanStrategy(), nil
+	default:
+		return nil, fmt.Errorf("unrecognized scoring strategy %s", info.Type)
+	}
+}
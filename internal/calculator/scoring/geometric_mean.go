@@ -0,0 +1,45 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scoring
+
+import (
+	"math"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+// GeometricMeanStrategy combines the weighted pass ratio with the confidence of each lower layer using their geometric
+// mean. Unlike the weighted ratio, a deep stack of mostly trusted layers is not penalized just for being deep.
+type GeometricMeanStrategy struct{}
+
+func NewGeometricMeanStrategy() ScoringStrategy {
+	return &GeometricMeanStrategy{}
+}
+
+func (s *GeometricMeanStrategy) Name() config.ScoringType {
+	return config.GeometricMean
+}
+
+func (s *GeometricMeanStrategy) Confidence(annotations []documents.Annotation, policy policies.DcfPolicy, lowerLayers []float64) float64 {
+	passedWeight, totalWeight := weigh(annotations, policy)
+	product := passedWeight / totalWeight
+
+	for _, c := range lowerLayers {
+		product *= c
+	}
+	return math.Pow(product, 1/float64(len(lowerLayers)+1))
+}
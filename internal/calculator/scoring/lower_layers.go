@@ -0,0 +1,106 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scoring
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/db"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// ScoreFinder looks up the current score of a layer for a tag or host value under a policy. It returns
+// db.ErrScoreNotFound if the layer has not been scored for the value.
+type ScoreFinder interface {
+	QueryScoreByTag(ctx context.Context, tag string, layer contracts.LayerType, policy string) (documents.Score, error)
+}
+
+// LowerLayers finds the confidence scores calculated under the policy for the lower layers the annotations' layer is
+// built on, such as the CICD pipelines that built the apps that processed the piece of data and the OS on which the
+// app is running. Missing scores are penalized as configured and listed. It is up to the caller to act on the ones
+// with the defer penalty, which have no stand-in. A score that cannot be looked up for any other reason is returned as
+// an error, since leaving the layer out could raise the confidence above the true one.
+func LowerLayers(
+	ctx context.Context,
+	finder ScoreFinder,
+	stack *types.LayerGraph,
+	missing map[contracts.LayerType]config.MissingInfo,
+	annotations []documents.Annotation,
+	policy string,
+) ([]Dependency, []documents.MissingScore, error) {
+	var dependencies []Dependency
+	var missingScores []documents.MissingScore
+	for _, d := range stack.DependsOn(annotations[0].Layer) {
+		dependency := Dependency{
+			Layer:  d.DependsOn,
+			JoinOn: d.JoinOn,
+			Scores: make(map[string]documents.Score),
+		}
+		for _, annotation := range annotations {
+			field := JoinValue(annotation, d.JoinOn)
+			// Check if the confidence for the field is already computed
+			if _, exists := dependency.Scores[field]; exists {
+				continue
+			}
+			fieldScore, err := finder.QueryScoreByTag(ctx, field, d.DependsOn, policy)
+			if errors.Is(err, db.ErrScoreNotFound) {
+				missingScores = penalize(field, d.DependsOn, missing, dependency.Scores, missingScores)
+			} else if err != nil {
+				return nil, nil, err
+			} else {
+				dependency.Scores[field] = fieldScore
+			}
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies, missingScores, nil
+}
+
+// penalize applies the configured action when the lower layer has no score for the field value of an annotation. A
+// stand-in score without a key is added to the field scores when the action supplies a confidence.
+func penalize(
+	field string,
+	layer contracts.LayerType,
+	missing map[contracts.LayerType]config.MissingInfo,
+	fieldScores map[string]documents.Score,
+	missingScores []documents.MissingScore,
+) []documents.MissingScore {
+	exists := slices.ContainsFunc(missingScores, func(m documents.MissingScore) bool {
+		return m.Field == field && m.Layer == layer
+	})
+	if exists {
+		return missingScores
+	}
+
+	info, ok := missing[layer]
+	if !ok {
+		info = config.MissingInfo{Action: config.IgnoreMissing}
+	}
+	m := documents.MissingScore{
+		Field:   field,
+		Layer:   layer,
+		Penalty: string(info.Action),
+	}
+	if standIn, ok := StandIn(layer, info); ok {
+		m.Confidence = standIn.Confidence
+		fieldScores[field] = standIn
+	}
+	return append(missingScores, m)
+}
@@ -0,0 +1,160 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/db"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// fakeFinder returns the scores it holds by layer and field value, or the error set for the field value.
+type fakeFinder struct {
+	scores  map[contracts.LayerType]map[string]documents.Score
+	failing map[string]error
+}
+
+func (f fakeFinder) QueryScoreByTag(
+	ctx context.Context,
+	tag string,
+	layer contracts.LayerType,
+	policy string,
+) (documents.Score, error) {
+	if err, ok := f.failing[tag]; ok {
+		return documents.Score{}, err
+	}
+	if s, ok := f.scores[layer][tag]; ok {
+		return s, nil
+	}
+	return documents.Score{}, fmt.Errorf("%w for tag: %s", db.ErrScoreNotFound, tag)
+}
+
+func TestLowerLayers(t *testing.T) {
+	stack, err := types.NewLayerGraph([]config.LayerDependency{
+		{Layer: contracts.Application, DependsOn: contracts.CiCd, JoinOn: config.JoinTag},
+		{Layer: contracts.Application, DependsOn: contracts.Os, JoinOn: config.JoinHost},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder := fakeFinder{
+		scores: map[contracts.LayerType]map[string]documents.Score{
+			contracts.CiCd: {"tag-a": {DataRef: "cicd-1", Confidence: 0.9}},
+			contracts.Os:   {"host-a": {DataRef: "os-1", Confidence: 0.8}},
+		},
+	}
+	annotations := []documents.Annotation{
+		{Tag: "tag-a", Host: "host-a", Layer: contracts.Application, Kind: "tpm"},
+		{Tag: "tag-a", Host: "host-a", Layer: contracts.Application, Kind: "tls"},
+		{Tag: "tag-b", Host: "host-c", Layer: contracts.Application, Kind: "src"},
+	}
+
+	tests := []struct {
+		name            string
+		missing         map[contracts.LayerType]config.MissingInfo
+		expectedCiCd    map[string]float64
+		expectedOs      map[string]float64
+		expectedMissing []documents.MissingScore
+	}{
+		{"ignore", nil,
+			map[string]float64{"tag-a": 0.9},
+			map[string]float64{"host-a": 0.8},
+			[]documents.MissingScore{
+				{Field: "tag-b", Layer: contracts.CiCd, Penalty: string(config.IgnoreMissing)},
+				{Field: "host-c", Layer: contracts.Os, Penalty: string(config.IgnoreMissing)},
+			}},
+		{"stand in", map[contracts.LayerType]config.MissingInfo{
+			contracts.CiCd: {Action: config.MultiplyMissing, Multiplier: 0.5},
+			contracts.Os:   {Action: config.ZeroMissing},
+		},
+			map[string]float64{"tag-a": 0.9, "tag-b": 0.5},
+			map[string]float64{"host-a": 0.8, "host-c": 0},
+			[]documents.MissingScore{
+				{Field: "tag-b", Layer: contracts.CiCd, Penalty: string(config.MultiplyMissing), Confidence: 0.5},
+				{Field: "host-c", Layer: contracts.Os, Penalty: string(config.ZeroMissing)},
+			}},
+		{"defer", map[contracts.LayerType]config.MissingInfo{contracts.Os: {Action: config.DeferMissing}},
+			map[string]float64{"tag-a": 0.9},
+			map[string]float64{"host-a": 0.8},
+			[]documents.MissingScore{
+				{Field: "tag-b", Layer: contracts.CiCd, Penalty: string(config.IgnoreMissing)},
+				{Field: "host-c", Layer: contracts.Os, Penalty: string(config.DeferMissing)},
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependencies, missing, err := LowerLayers(context.Background(), finder, stack, tt.missing, annotations,
+				"default")
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(dependencies) != 2 {
+				t.Fatalf("expected 2 dependencies, received %d", len(dependencies))
+			}
+			for _, d := range dependencies {
+				expected := tt.expectedCiCd
+				if d.Layer == contracts.Os {
+					expected = tt.expectedOs
+				}
+				if len(d.Scores) != len(expected) {
+					t.Errorf("expected %s scores %v, received %v", d.Layer, expected, d.Scores)
+				}
+				for field, confidence := range expected {
+					if s, ok := d.Scores[field]; !ok || s.Confidence != confidence {
+						t.Errorf("expected %s score %v for %s, received %v", d.Layer, confidence, field, s.Confidence)
+					}
+				}
+			}
+			if !slices.Equal(missing, tt.expectedMissing) {
+				t.Errorf("expected missing %v, received %v", tt.expectedMissing, missing)
+			}
+		})
+	}
+}
+
+func TestLowerLayersLookupFailure(t *testing.T) {
+	stack, err := types.NewLayerGraph([]config.LayerDependency{
+		{Layer: contracts.Application, DependsOn: contracts.Os, JoinOn: config.JoinHost},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := errors.New("connection refused")
+	finder := fakeFinder{
+		scores:  map[contracts.LayerType]map[string]documents.Score{contracts.Os: {"host-a": {Confidence: 0.8}}},
+		failing: map[string]error{"host-b": failure},
+	}
+	annotations := []documents.Annotation{
+		{Host: "host-a", Layer: contracts.Application, Kind: "tpm"},
+		{Host: "host-b", Layer: contracts.Application, Kind: "tls"},
+	}
+
+	// A failed lookup is not a missing score, so it is returned rather than penalized or left out
+	dependencies, missing, err := LowerLayers(context.Background(), finder, stack, nil, annotations, "default")
+	if !errors.Is(err, failure) {
+		t.Errorf("expected error %v, received %v", failure, err)
+	}
+	if dependencies != nil || missing != nil {
+		t.Errorf("expected no dependencies or missing scores, received %v and %v", dependencies, missing)
+	}
+}
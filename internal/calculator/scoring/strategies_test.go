@@ -0,0 +1,320 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scoring
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/message"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/decay"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+func TestStrategyConfidence(t *testing.T) {
+	annotations := []documents.Annotation{
+		{Kind: "tpm", IsSatisfied: true},
+		{Kind: "tls", IsSatisfied: true},
+		{Kind: "pki", IsSatisfied: false},
+	}
+	policy := policies.DcfPolicy{
+		Name: "test",
+		Weights: []policies.Weight{
+			{AnnotationKey: "tpm", Value: 2},
+			{AnnotationKey: "tls", Value: 1},
+			{AnnotationKey: "pki", Value: 1},
+		},
+	}
+	lowerLayers := []float64{0.5}
+
+	tests := []struct {
+		name     string
+		info     config.ScoringInfo
+		expected float64
+	}{
+		{"weighted ratio", config.ScoringInfo{Type: config.WeightedRatio}, 0.375},
+		{"weighted ratio default", config.ScoringInfo{}, 0.375},
+		{"weighted minimum", config.ScoringInfo{Type: config.WeightedMinimum}, 0.5},
+		{"bayesian", config.ScoringInfo{Type: config.Bayesian}, 0.333333},
+		{"bayesian with prior", config.ScoringInfo{Type: config.Bayesian, Config: config.BayesianConfig{Alpha: 4, Beta: 1}}, 0.388889},
+		{"geometric mean", config.ScoringInfo{Type: config.GeometricMean}, 0.612372},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := NewScoringStrategy(tt.info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := strategy.Confidence(annotations, policy, lowerLayers)
+			if math.Abs(result-tt.expected) > 0.000001 {
+				t.Errorf("expected confidence %v, received %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestNewScoreRecordsStrategy(t *testing.T) {
+	annotations := []documents.Annotation{
+		{Kind: "tpm", Tag: "a", IsSatisfied: true},
+		{Kind: "tls", Tag: "a", IsSatisfied: false},
+	}
+	s := NewScore(NewGeometricMeanStrategy(), nil, "key", annotations, policies.DcfPolicy{Name: "default"}, nil)
+	if s.Strategy != string(config.GeometricMean) {
+		t.Errorf("expected strategy %s, received %s", config.GeometricMean, s.Strategy)
+	}
+	if s.Policy != "default" || s.Passed != 1 || s.Count != 2 || s.Confidence != 0.5 || s.Band != "suspect" {
+		t.Errorf("unexpected score document %+v", s)
+	}
+}
+
+func TestNewScoreExplanation(t *testing.T) {
+	annotations := []documents.Annotation{
+		{Key: "1", Kind: "tpm", Tag: "a", Host: "h", IsSatisfied: true},
+		{Key: "2", Kind: "tls", Tag: "a", Host: "h", IsSatisfied: false},
+	}
+	policy := policies.DcfPolicy{
+		Name:    "default",
+		Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 3}},
+	}
+	dependencies := []Dependency{
+		{Layer: contracts.CiCd, JoinOn: config.JoinTag, Scores: map[string]documents.Score{
+			"a": {Key: documents.NewULID(), Confidence: 0.8},
+		}},
+		{Layer: contracts.Os, JoinOn: config.JoinHost, Scores: map[string]documents.Score{}},
+	}
+
+	s := NewScore(NewWeightedRatioStrategy(), nil, "key", annotations, policy, dependencies)
+	e := s.Explanation
+	if e == nil {
+		t.Fatal("expected an explanation")
+	}
+	if e.Policy != "default" || e.Strategy != string(config.WeightedRatio) {
+		t.Errorf("unexpected policy or strategy %+v", e)
+	}
+	if len(e.Annotations) != 2 || e.Annotations[0].Weight != 3 || e.Annotations[0].Contribution != 0.75 ||
+		e.Annotations[1].Weight != 1 || e.Annotations[1].Contribution != 0 {
+		t.Errorf("unexpected annotation contributions %+v", e.Annotations)
+	}
+	if len(e.LowerLayers) != 2 {
+		t.Fatalf("unexpected lower layers %+v", e.LowerLayers)
+	}
+	if cicd := e.LowerLayers[0]; !cicd.Counted || len(cicd.Scores) != 1 || cicd.Confidence != 0.8 {
+		t.Errorf("unexpected cicd layer %+v", cicd)
+	}
+	if os := e.LowerLayers[1]; os.Counted || len(os.Scores) != 0 || os.Confidence != 0 {
+		t.Errorf("unexpected os layer %+v", os)
+	}
+	if s.Confidence != 0.6 {
+		t.Errorf("expected confidence 0.6, received %v", s.Confidence)
+	}
+}
+
+func TestNewScoreDecaysLowerLayers(t *testing.T) {
+	annotations := []documents.Annotation{
+		{Kind: "tpm", Tag: "a", IsSatisfied: true},
+	}
+	dependencies := []Dependency{
+		{Layer: contracts.CiCd, JoinOn: config.JoinTag, Scores: map[string]documents.Score{
+			"a": {Key: documents.NewULID(), Confidence: 0.8, Timestamp: time.Now().Add(-48 * time.Hour)},
+		}},
+	}
+
+	s := NewScore(NewWeightedRatioStrategy(), decay.NewExponentialDecay(24*time.Hour), "key", annotations,
+		policies.DcfPolicy{}, dependencies)
+	if s.Confidence != 0.2 {
+		t.Errorf("expected confidence 0.2, received %v", s.Confidence)
+	}
+	if s.Explanation.Decay != string(config.ExponentialDecay) || len(s.Explanation.LowerLayers[0].Scores) != 1 {
+		t.Fatalf("unexpected explanation %+v", s.Explanation)
+	}
+	if factor := s.Explanation.LowerLayers[0].Scores[0].DecayFactor; math.Abs(factor-0.25) > 0.0001 {
+		t.Errorf("expected decay factor 0.25, received %v", factor)
+	}
+
+	s = NewScore(NewWeightedRatioStrategy(), decay.NewCutoffDecay(24*time.Hour), "key", annotations,
+		policies.DcfPolicy{}, dependencies)
+	if s.Confidence != 0 {
+		t.Errorf("expected an expired lower layer to zero the confidence, received %v", s.Confidence)
+	}
+}
+
+func TestNewScoreCountsStandIns(t *testing.T) {
+	annotations := []documents.Annotation{
+		{Kind: "tpm", Tag: "a", Host: "h", IsSatisfied: true},
+	}
+	tests := []struct {
+		name     string
+		standIn  documents.Score
+		expected float64
+	}{
+		{"multiplier", documents.Score{Confidence: 0.5}, 0.5},
+		{"zero", documents.Score{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependencies := []Dependency{
+				{Layer: contracts.CiCd, JoinOn: config.JoinTag, Scores: map[string]documents.Score{"a": tt.standIn}},
+			}
+			s := NewScore(NewWeightedRatioStrategy(), nil, "key", annotations, policies.DcfPolicy{}, dependencies)
+			if s.Confidence != tt.expected {
+				t.Errorf("expected confidence %v, received %v", tt.expected, s.Confidence)
+			}
+			if scores := s.Explanation.LowerLayers[0].Scores; len(scores) != 0 {
+				t.Errorf("stand-ins should not be listed as lower layer scores %+v", scores)
+			}
+		})
+	}
+}
+
+func TestNewScoreVetoes(t *testing.T) {
+	annotations := []documents.Annotation{
+		{Kind: "tpm", Tag: "a", Layer: contracts.Application, IsSatisfied: false},
+		{Kind: "tls", Tag: "a", Layer: contracts.Application, IsSatisfied: true},
+		{Kind: "pki", Tag: "a", Layer: contracts.Application, IsSatisfied: true},
+	}
+	appOnly := false
+	tests := []struct {
+		name     string
+		tpm      policies.Weight
+		expected float64
+		vetoed   bool
+	}{
+		{"not required", policies.Weight{AnnotationKey: "tpm", Value: 0.5}, 0.8, false},
+		{"zeroed", policies.Weight{AnnotationKey: "tpm", Value: 0.5, Required: true}, 0, true},
+		{"capped", policies.Weight{AnnotationKey: "tpm", Value: 0.5, Required: true, Cap: 0.3}, 0.3, true},
+		{"cap above confidence", policies.Weight{AnnotationKey: "tpm", Value: 0.5, Required: true, Cap: 0.9}, 0.8, true},
+		{"not required for layer", policies.Weight{AnnotationKey: "tpm", Value: 0.5, Required: true,
+			Layers: map[contracts.LayerType]policies.LayerWeight{contracts.Application: {Required: &appOnly}}}, 0.8, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := policies.DcfPolicy{Name: "default", Weights: []policies.Weight{tt.tpm}}
+			s := NewScore(NewWeightedRatioStrategy(), nil, "key", annotations, policy, nil)
+			if s.Confidence != tt.expected {
+				t.Errorf("expected confidence %v, received %v", tt.expected, s.Confidence)
+			}
+			if c := s.Explanation.Annotations[0]; c.Vetoed != tt.vetoed || c.Weight != 0.5 {
+				t.Errorf("unexpected contribution %+v", c)
+			}
+		})
+	}
+}
+
+func TestNewScoreVetoesAbsentKinds(t *testing.T) {
+	annotations := []documents.Annotation{
+		{Kind: "tls", Tag: "a", Layer: contracts.Application, Action: message.ActionCreate, IsSatisfied: true},
+		{Kind: "pki", Tag: "a", Layer: contracts.Application, Action: message.ActionCreate, IsSatisfied: true},
+	}
+	notRequired := false
+	capped := 0.3
+	tests := []struct {
+		name     string
+		tpm      policies.Weight
+		expected float64
+		absent   []string
+	}{
+		{"not required", policies.Weight{AnnotationKey: "tpm", Value: 1}, 1, nil},
+		{"zeroed", policies.Weight{AnnotationKey: "tpm", Value: 1, Required: true}, 0, []string{"tpm"}},
+		{"capped", policies.Weight{AnnotationKey: "tpm", Value: 1, Required: true, Cap: 0.3}, 0.3, []string{"tpm"}},
+		{"capped for layer", policies.Weight{AnnotationKey: "tpm", Value: 1, Required: true,
+			Layers: map[contracts.LayerType]policies.LayerWeight{contracts.Application: {Cap: &capped}}}, 0.3, []string{"tpm"}},
+		{"not required for layer", policies.Weight{AnnotationKey: "tpm", Value: 1, Required: true,
+			Layers: map[contracts.LayerType]policies.LayerWeight{contracts.Application: {Required: &notRequired}}}, 1, nil},
+		{"not required for action", policies.Weight{AnnotationKey: "tpm", Value: 1, Required: true,
+			Actions: map[message.SdkAction]policies.LayerWeight{message.ActionCreate: {Required: &notRequired}}}, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := policies.DcfPolicy{Name: "default", Weights: []policies.Weight{tt.tpm}}
+			s := NewScore(NewWeightedRatioStrategy(), nil, "key", annotations, policy, nil)
+			if s.Confidence != tt.expected {
+				t.Errorf("expected confidence %v, received %v", tt.expected, s.Confidence)
+			}
+			if !slices.Equal(s.Explanation.AbsentRequired, tt.absent) {
+				t.Errorf("expected absent required kinds %v, received %v", tt.absent, s.Explanation.AbsentRequired)
+			}
+
+			if tt.absent == nil {
+				return
+			}
+			// Data without the required kind never scores higher than data where it failed
+			failed := append(slices.Clone(annotations), documents.Annotation{
+				Kind: "tpm", Tag: "a", Layer: contracts.Application, Action: message.ActionCreate, IsSatisfied: false,
+			})
+			f := NewScore(NewWeightedRatioStrategy(), nil, "key", failed, policy, nil)
+			if s.Confidence > f.Confidence {
+				t.Errorf("expected absent tpm to score no higher than failed tpm, received %v and %v", s.Confidence,
+					f.Confidence)
+			}
+		})
+	}
+}
+
+func TestNewScoreRequiresSignatures(t *testing.T) {
+	valid, invalid := true, false
+	annotations := []documents.Annotation{
+		{Kind: "tpm", Tag: "a", IsSatisfied: true, SignatureValid: &valid},
+		{Kind: "tls", Tag: "a", IsSatisfied: true, SignatureValid: &invalid},
+		{Kind: "pki", Tag: "a", IsSatisfied: true},
+	}
+	tests := []struct {
+		name       string
+		require    bool
+		expected   float64
+		unverified []bool
+	}{
+		{"not required", false, 1, []bool{false, false, false}},
+		{"required", true, 0.33, []bool{false, true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := policies.DcfPolicy{Name: "default", RequireSignatures: tt.require}
+			s := NewScore(NewWeightedRatioStrategy(), nil, "key", annotations, policy, nil)
+			if s.Confidence != tt.expected {
+				t.Errorf("expected confidence %v, received %v", tt.expected, s.Confidence)
+			}
+			for i, c := range s.Explanation.Annotations {
+				if c.Unverified != tt.unverified[i] {
+					t.Errorf("unexpected contribution %+v", c)
+				}
+			}
+			if !annotations[1].IsSatisfied {
+				t.Error("expected the supplied annotations to be left untouched")
+			}
+		})
+	}
+}
+
+func TestNewScoreWeighsActions(t *testing.T) {
+	annotations := []documents.Annotation{
+		{Kind: "tpm", Tag: "a", IsSatisfied: true, Action: message.ActionCreate},
+		{Kind: "tpm", Tag: "a", IsSatisfied: false, Action: message.ActionPublish},
+	}
+	policy := policies.DcfPolicy{Name: "default", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 1,
+		Actions: map[message.SdkAction]policies.LayerWeight{message.ActionPublish: {Value: 3}}}}}
+
+	s := NewScore(NewWeightedRatioStrategy(), nil, "key", annotations, policy, nil)
+	if s.Confidence != 0.25 {
+		t.Errorf("expected confidence 0.25, received %v", s.Confidence)
+	}
+	if c := s.Explanation.Annotations[1]; c.Action != message.ActionPublish || c.Weight != 3 {
+		t.Errorf("unexpected contribution %+v", c)
+	}
+}
@@ -0,0 +1,45 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scoring
+
+import (
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+// WeightedMinimumStrategy treats the stack as only being as trustworthy as its weakest layer. The confidence is the
+// minimum of the weighted pass ratio and the confidence of each lower layer.
+type WeightedMinimumStrategy struct{}
+
+func NewWeightedMinimumStrategy() ScoringStrategy {
+	return &WeightedMinimumStrategy{}
+}
+
+func (s *WeightedMinimumStrategy) Name() config.ScoringType {
+	return config.WeightedMinimum
+}
+
+func (s *WeightedMinimumStrategy) Confidence(annotations []documents.Annotation, policy policies.DcfPolicy, lowerLayers []float64) float64 {
+	passedWeight, totalWeight := weigh(annotations, policy)
+	confidence := passedWeight / totalWeight
+
+	for _, c := range lowerLayers {
+		if c < confidence {
+			confidence = c
+		}
+	}
+	return confidence
+}
@@ -0,0 +1,44 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scoring
+
+import (
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+// WeightedRatioStrategy divides the weight of the satisfied annotations by the total weight of all annotations, then
+// multiplies the result by the confidence of each lower layer.
+type WeightedRatioStrategy struct{}
+
+func NewWeightedRatioStrategy() ScoringStrategy {
+	return &WeightedRatioStrategy{}
+}
+
+func (s *WeightedRatioStrategy) Name() config.ScoringType {
+	return config.WeightedRatio
+}
+
+func (s *WeightedRatioStrategy) Confidence(annotations []documents.Annotation, policy policies.DcfPolicy, lowerLayers []float64) float64 {
+	passedWeight, totalWeight := weigh(annotations, policy)
+	confidence := passedWeight / totalWeight
+
+	// The confidence should be influenced by the lower layers if they have a calculated confidence
+	for _, c := range lowerLayers {
+		confidence *= c
+	}
+	return confidence
+}
@@ -0,0 +1,101 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	populator_api "github.com/project-alvarium/scoring-apps-go/internal/populator-api"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+// TestSimulatorMatchesCalculator checks that the simulate endpoint of the populator API arrives at the same score as the
+// calculator for the same annotations, lower layer scores and settings.
+func TestSimulatorMatchesCalculator(t *testing.T) {
+	p := policies.DcfPolicy{
+		Name: "default",
+		Weights: []policies.Weight{
+			{AnnotationKey: "tpm", Value: 3},
+			{AnnotationKey: "tls", Value: 1},
+		},
+	}
+	annotations := []documents.Annotation{
+		{Key: "a1", DataRef: "app-1", Host: "host-a", Layer: contracts.Application, Kind: "tpm", IsSatisfied: true},
+		{Key: "a2", DataRef: "app-1", Host: "host-b", Layer: contracts.Application, Kind: "tls", IsSatisfied: true},
+		{Key: "a3", DataRef: "app-1", Host: "host-c", Layer: contracts.Application, Kind: "src", IsSatisfied: false},
+	}
+
+	tests := []struct {
+		name    string
+		missing map[contracts.LayerType]config.MissingInfo
+	}{
+		{"ignore", nil},
+		{"multiplier", map[contracts.LayerType]config.MissingInfo{
+			contracts.Os: {Action: config.MultiplyMissing, Multiplier: 0.5},
+		}},
+		{"zero", map[contracts.LayerType]config.MissingInfo{contracts.Os: {Action: config.ZeroMissing}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := newFakeGraph()
+			graph.annotations["app-1"] = annotations
+			graph.addScore("os-1", contracts.Os, p.Name, 0.8, "host-a")
+			graph.addScore("os-2", contracts.Os, p.Name, 0.6, "host-b")
+
+			c := newTestCalculator(t, graph, false, config.NoPropagation, p)
+			c.missing = tt.missing
+			c.score(context.Background(), "app-1")
+			current := graph.current("app-1")
+			if len(current) != 1 {
+				t.Fatalf("expected the calculator to store a score, received %d", len(current))
+			}
+			expected := current[0]
+
+			simulator, err := populator_api.NewSimulator(populator_api.SimulateConfig{
+				Scoring: config.ScoringInfo{Type: config.WeightedRatio},
+				Decay:   config.DecayInfo{Type: config.NoDecay},
+				Missing: tt.missing,
+				Stack:   []config.LayerDependency{{Layer: contracts.Application, DependsOn: contracts.Os, JoinOn: config.JoinHost}},
+			}, graph, newTestLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			simulated, err := simulator.Simulate(context.Background(), "app-1", annotations, p, p.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if simulated.Confidence != expected.Confidence || simulated.Band != expected.Band {
+				t.Errorf("expected confidence %v (%s), simulated %v (%s)", expected.Confidence, expected.Band,
+					simulated.Confidence, simulated.Band)
+			}
+			if simulated.Passed != expected.Passed || simulated.Count != expected.Count {
+				t.Errorf("expected %d of %d passed, simulated %d of %d", expected.Passed, expected.Count,
+					simulated.Passed, simulated.Count)
+			}
+			if !slices.Equal(simulated.Missing, expected.Missing) {
+				t.Errorf("expected missing %v, simulated %v", expected.Missing, simulated.Missing)
+			}
+			if !slices.Equal(simulated.Omitted, []string{"propagation", "decision"}) {
+				t.Errorf("expected propagation and decisions to be listed as omitted, received %v", simulated.Omitted)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+func NewKeyStore(info config.QueueInfo) (KeyStore, error) {
+	switch info.Type {
+	case config.MemoryQueue, "":
+		return NewMemoryStore(), nil
+	case config.FileQueue:
+		cfg, ok := info.Config.(config.FileQueueConfig)
+		if !ok {
+			return nil, errors.New("invalid cast for file queue config")
+		}
+		return NewFileStore(cfg)
+	default:
+		return nil, fmt.Errorf("unrecognized queue type %s", info.Type)
+	}
+}
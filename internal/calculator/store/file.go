@@ -0,0 +1,192 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package store
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+const (
+	opAdd            byte = '+'
+	opRemove         byte = '-'
+	compactThreshold int  = 1000 // Minimum number of log entries before the log is considered for compaction
+)
+
+// FileStore persists outstanding keys to an append-only log on disk. Every Add and Remove is written as a single line
+// and synced before returning. The log is replayed when the store is opened and is periodically rewritten to contain
+// only the outstanding keys.
+type FileStore struct {
+	entries int
+	file    *os.File
+	keys    map[string]bool
+	mutex   sync.Mutex
+	path    string
+}
+
+func NewFileStore(cfg config.FileQueueConfig) (KeyStore, error) {
+	s := FileStore{
+		keys: make(map[string]bool),
+		path: cfg.Path,
+	}
+	err := s.replay()
+	if err != nil {
+		return nil, err
+	}
+	// Compacting here also opens the log for writing
+	err = s.compact()
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *FileStore) Add(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.keys[key] {
+		return nil
+	}
+	err := s.append(opAdd, key)
+	if err != nil {
+		return err
+	}
+	s.keys[key] = true
+	return nil
+}
+
+func (s *FileStore) Remove(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.keys[key] {
+		return nil
+	}
+	err := s.append(opRemove, key)
+	if err != nil {
+		return err
+	}
+	delete(s.keys, key)
+
+	if s.entries >= compactThreshold && s.entries > 2*len(s.keys) {
+		return s.compact()
+	}
+	return nil
+}
+
+func (s *FileStore) Load() ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var keys []string
+	for k := range s.keys {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (s *FileStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileStore) append(op byte, key string) error {
+	if s.file == nil {
+		return fmt.Errorf("key store %s is closed", s.path)
+	}
+	_, err := s.file.WriteString(string(op) + key + "\n")
+	if err != nil {
+		return err
+	}
+	s.entries++
+	return s.file.Sync()
+}
+
+// replay rebuilds the set of outstanding keys from the log. A missing log simply means there is nothing to recover.
+func (s *FileStore) replay() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case opAdd:
+			s.keys[line[1:]] = true
+		case opRemove:
+			delete(s.keys, line[1:])
+		}
+	}
+	return scanner.Err()
+}
+
+// compact rewrites the log so that it only contains the outstanding keys. The new log is written to a temporary file
+// first and then renamed so that a crash during compaction leaves the previous log intact.
+func (s *FileStore) compact() error {
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for k := range s.keys {
+		w.WriteByte(opAdd)
+		w.WriteString(k)
+		w.WriteByte('\n')
+	}
+	err = w.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	err = os.Rename(tmpPath, s.path)
+	if err != nil {
+		return err
+	}
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	s.entries = len(s.keys)
+	return nil
+}
@@ -0,0 +1,81 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+func TestFileStoreRecovery(t *testing.T) {
+	cfg := config.FileQueueConfig{Path: filepath.Join(t.TempDir(), "keys.log")}
+
+	s, err := NewFileStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"a", "b", "c"} {
+		if err = s.Add(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = s.Remove("b"); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// Simulate a restart by reopening the same log
+	s, err = NewFileStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	keys, _ := s.Load()
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, received %v", keys)
+	}
+	for _, k := range keys {
+		if k != "a" && k != "c" {
+			t.Errorf("unexpected key recovered %s", k)
+		}
+	}
+}
+
+func TestFileStoreCompaction(t *testing.T) {
+	cfg := config.FileQueueConfig{Path: filepath.Join(t.TempDir(), "keys.log")}
+
+	s, err := NewFileStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i := 0; i < compactThreshold; i++ {
+		s.Add("key")
+		s.Remove("key")
+	}
+	fs := s.(*FileStore)
+	if fs.entries >= compactThreshold {
+		t.Errorf("expected log to be compacted, %v entries remain", fs.entries)
+	}
+	if err = s.Add("last"); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := s.Load()
+	if len(keys) != 1 || keys[0] != "last" {
+		t.Errorf("unexpected keys after compaction %v", keys)
+	}
+}
@@ -0,0 +1,25 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package store
+
+// KeyStore records the keys received by the calculator until their score has been persisted. Keys still held by the
+// store when the calculator starts were either pending or in progress at the time of the last shutdown and need to be
+// scored again.
+type KeyStore interface {
+	Add(key string) error    // Add records a key that has been received
+	Remove(key string) error // Remove releases a key once it has been scored
+	Load() ([]string, error) // Load returns all outstanding keys
+	Close() error
+}
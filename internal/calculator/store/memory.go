@@ -0,0 +1,55 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package store
+
+import "sync"
+
+// MemoryStore keeps outstanding keys in memory only. Nothing is recovered after a restart.
+type MemoryStore struct {
+	keys  map[string]bool
+	mutex sync.Mutex
+}
+
+func NewMemoryStore() KeyStore {
+	return &MemoryStore{keys: make(map[string]bool)}
+}
+
+func (s *MemoryStore) Add(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.keys[key] = true
+	return nil
+}
+
+func (s *MemoryStore) Remove(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.keys, key)
+	return nil
+}
+
+func (s *MemoryStore) Load() ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var keys []string
+	for k := range s.keys {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
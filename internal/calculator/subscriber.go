@@ -16,38 +16,47 @@ package calculator
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"sync"
 
 	SdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
 	SdkInterfaces "github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/deadletter"
 	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/factories"
 	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/interfaces"
 	"github.com/project-alvarium/scoring-apps-go/pkg/msg"
 )
 
 type Subscriber struct {
-	chKeys   chan string
-	instance interfaces.Subscriber
-	logger   SdkInterfaces.Logger
+	chKeys      chan string
+	deadLetters *deadletter.DeadLetters // deadLetters keeps the messages that cannot be decoded, if enabled
+	instance    interfaces.Subscriber
+	logger      SdkInterfaces.Logger
 }
 
-func NewSubscriber(endpoint SdkConfig.StreamInfo, chKeys chan string, logger SdkInterfaces.Logger) (Subscriber, error) {
+func NewSubscriber(
+	endpoint SdkConfig.StreamInfo,
+	chKeys chan string,
+	deadLetters *deadletter.DeadLetters,
+	logger SdkInterfaces.Logger,
+) (Subscriber, error) {
 	t, err := factories.NewSubscriber(endpoint)
 	if err != nil {
 		return Subscriber{}, err
 	}
 	return Subscriber{
-		chKeys:   chKeys,
-		instance: t,
-		logger:   logger,
+		chKeys:      chKeys,
+		deadLetters: deadLetters,
+		instance:    t,
+		logger:      logger,
 	}, nil
 }
 
 func (s *Subscriber) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bool {
 	cancelled := false
 	chErrors := make(chan error)
-	go logErrors(chErrors, s.logger)
+	go s.handleErrors(ctx, chErrors)
 
 	chMessages := make(chan msg.SubscribeWrapper)
 	go s.instance.Subscribe(ctx, chMessages, chErrors)
@@ -83,6 +92,22 @@ func (s *Subscriber) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) b
 	return true
 }
 
+// handleErrors logs the errors reported by the subscriber and dead-letters the messages that could not be decoded.
+func (s *Subscriber) handleErrors(ctx context.Context, ch chan error) {
+	for {
+		e, ok := <-ch
+		if !ok {
+			return
+		}
+		s.logger.Error(e.Error())
+
+		var malformed *interfaces.MessageError
+		if s.deadLetters != nil && errors.As(e, &malformed) {
+			s.deadLetters.Record(ctx, malformed.Topic, malformed.Payload, malformed.Err)
+		}
+	}
+}
+
 func logErrors(ch chan error, logger SdkInterfaces.Logger) {
 	for {
 		e, ok := <-ch
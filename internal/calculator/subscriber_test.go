@@ -0,0 +1,85 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package calculator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/deadletter"
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+func TestHandleErrorsDeadLettersMalformedMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"malformed", &interfaces.MessageError{Topic: "topic", Payload: []byte("{"), Err: errors.New("unexpected end")}, 1},
+		{"other", errors.New("connection lost"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeLetterStore{}
+			s := Subscriber{
+				chKeys:      make(chan string),
+				deadLetters: deadletter.NewDeadLettersWithStore(store, nil, deadletter.SourceCalculator, newTestLogger()),
+				logger:      newTestLogger(),
+			}
+			ch := make(chan error)
+			done := make(chan struct{})
+			go func() {
+				s.handleErrors(context.Background(), ch)
+				close(done)
+			}()
+			ch <- tt.err
+			close(ch)
+			<-done
+
+			if len(store.letters) != tt.expected {
+				t.Fatalf("expected %d dead letters, received %d", tt.expected, len(store.letters))
+			}
+			if tt.expected > 0 {
+				letter := store.letters[0]
+				if letter.Source != deadletter.SourceCalculator || letter.Topic != "topic" ||
+					string(letter.Payload) != "{" || letter.Error != "unexpected end" {
+					t.Errorf("unexpected dead letter %+v", letter)
+				}
+			}
+		})
+	}
+}
+
+// fakeLetterStore keeps dead letters in memory.
+type fakeLetterStore struct {
+	letters []documents.DeadLetter
+}
+
+func (s *fakeLetterStore) Init(ctx context.Context) error {
+	return nil
+}
+
+func (s *fakeLetterStore) Add(ctx context.Context, letter documents.DeadLetter) error {
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+func (s *fakeLetterStore) MarkReplayed(ctx context.Context, key string, replayed time.Time) error {
+	return nil
+}
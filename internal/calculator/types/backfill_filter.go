@@ -0,0 +1,51 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// BackfillFilter narrows down the unscored data picked up by a backfill. Zero values do not filter.
+type BackfillFilter struct {
+	From time.Time // From is the earliest data timestamp to include
+	To   time.Time // To is the latest data timestamp to include
+	Host string    // Host only includes data with at least one annotation made on the host
+}
+
+// NewBackfillFilter parses the RFC3339 timestamps bounding a backfill. Empty values do not filter.
+func NewBackfillFilter(from string, to string, host string) (BackfillFilter, error) {
+	filter := BackfillFilter{Host: host}
+	var err error
+	if filter.From, err = parseTimestamp(from); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimestamp(to); err != nil {
+		return filter, err
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return filter, fmt.Errorf("backfill range ends at %s before it starts at %s", to, from)
+	}
+	return filter, nil
+}
+
+// parseTimestamp reads an optional RFC3339 timestamp supplied on the command line.
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
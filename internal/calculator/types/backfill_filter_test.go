@@ -0,0 +1,59 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewBackfillFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		from         string
+		to           string
+		host         string
+		expected     BackfillFilter
+		expectsError bool
+	}{
+		{"empty", "", "", "", BackfillFilter{}, false},
+		{"host", "", "", "host-a", BackfillFilter{Host: "host-a"}, false},
+		{"range", "2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z", "",
+			BackfillFilter{From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			false},
+		{"open ended", "2024-01-01T00:00:00Z", "", "",
+			BackfillFilter{From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, false},
+		{"invalid from", "yesterday", "", "", BackfillFilter{}, true},
+		{"invalid to", "", "2024-01-02", "", BackfillFilter{}, true},
+		{"reversed", "2024-01-02T00:00:00Z", "2024-01-01T00:00:00Z", "", BackfillFilter{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewBackfillFilter(tt.from, tt.to, tt.host)
+			if tt.expectsError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !filter.From.Equal(tt.expected.From) || !filter.To.Equal(tt.expected.To) || filter.Host != tt.expected.Host {
+				t.Errorf("expected %v, received %v", tt.expected, filter)
+			}
+		})
+	}
+}
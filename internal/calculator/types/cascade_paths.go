@@ -0,0 +1,52 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package types
+
+import (
+	"slices"
+	"sync"
+)
+
+// CascadePaths remembers, for each key queued by a cascading re-score, the chain of keys whose new scores caused it to
+// be queued. The chain is used to stop a cascade from revisiting a key it has already passed through.
+type CascadePaths struct {
+	paths map[string][]string
+	mutex sync.Mutex
+}
+
+func NewCascadePaths() *CascadePaths {
+	return &CascadePaths{paths: make(map[string][]string)}
+}
+
+// Set records the chain of keys that caused the key to be queued. An existing chain is kept if it is shorter.
+func (cp *CascadePaths) Set(key string, path []string) {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	if existing, ok := cp.paths[key]; ok && len(existing) <= len(path) {
+		return
+	}
+	cp.paths[key] = slices.Clone(path)
+}
+
+// Take returns the chain recorded for the key and forgets it. Keys that were not queued by a cascade have no chain.
+func (cp *CascadePaths) Take(key string) []string {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	path := cp.paths[key]
+	delete(cp.paths, key)
+	return path
+}
@@ -0,0 +1,67 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package types
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+)
+
+// DeferredKeys holds the keys whose scoring was put off until a lower layer has been scored for a tag or host value.
+type DeferredKeys struct {
+	items map[contracts.LayerType]map[string]map[string]bool
+	mutex sync.Mutex
+}
+
+func NewDeferredKeys() *DeferredKeys {
+	return &DeferredKeys{items: make(map[contracts.LayerType]map[string]map[string]bool)}
+}
+
+// Add defers the key until the layer has a score for the field value.
+func (dk *DeferredKeys) Add(layer contracts.LayerType, field string, key string) {
+	dk.mutex.Lock()
+	defer dk.mutex.Unlock()
+
+	fields, ok := dk.items[layer]
+	if !ok {
+		fields = make(map[string]map[string]bool)
+		dk.items[layer] = fields
+	}
+	keys, ok := fields[field]
+	if !ok {
+		keys = make(map[string]bool)
+		fields[field] = keys
+	}
+	keys[key] = true
+}
+
+// Take returns and forgets the keys waiting for a score in the layer for any of the field values.
+func (dk *DeferredKeys) Take(layer contracts.LayerType, fields []string) []string {
+	dk.mutex.Lock()
+	defer dk.mutex.Unlock()
+
+	var result []string
+	for _, field := range fields {
+		for key := range dk.items[layer][field] {
+			if !slices.Contains(result, key) {
+				result = append(result, key)
+			}
+		}
+		delete(dk.items[layer], field)
+	}
+	return result
+}
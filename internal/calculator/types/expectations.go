@@ -0,0 +1,105 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package types
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+// Expectations holds the annotation kinds that must be received for a key in a given layer before its annotations
+// are considered complete.
+type Expectations struct {
+	byLayer   map[contracts.LayerType][]string
+	policies  []policies.DcfPolicy
+	usePolicy bool
+	mutex     sync.RWMutex
+}
+
+// NewExpectations resolves the expected annotation kinds from config. If the config asks for it, the kinds required by
+// any of the active policies for a layer are expected for every layer not explicitly listed.
+func NewExpectations(cfg config.CollectorInfo, dcfPolicies []policies.DcfPolicy) *Expectations {
+	e := Expectations{
+		byLayer:   cfg.Expected,
+		usePolicy: cfg.FromPolicy,
+	}
+	e.Reload(dcfPolicies)
+	return &e
+}
+
+// Reload replaces the policies the kinds are taken from, if the config asks for them, after the weights have changed.
+func (e *Expectations) Reload(dcfPolicies []policies.DcfPolicy) {
+	if !e.usePolicy {
+		return
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.policies = dcfPolicies
+}
+
+// Defined indicates whether any annotation kinds are expected for the layer.
+func (e *Expectations) Defined(layer contracts.LayerType) bool {
+	return len(e.kinds(layer)) > 0
+}
+
+// Missing returns the expected annotation kinds that are not present in the supplied annotations. All annotations are
+// assumed to belong to the same layer.
+func (e *Expectations) Missing(annotations []documents.Annotation) []string {
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	received := make(map[string]bool)
+	for _, a := range annotations {
+		received[a.Kind] = true
+	}
+
+	var missing []string
+	for _, kind := range e.kinds(annotations[0].Layer) {
+		if !received[kind] {
+			missing = append(missing, kind)
+		}
+	}
+	return missing
+}
+
+// kinds returns the kinds configured for the layer. Otherwise, if the config asks for it, a kind is expected when any
+// policy requires it for the layer once the layer's overrides are applied. Optional kinds are not waited for, since a
+// layer may never produce them.
+func (e *Expectations) kinds(layer contracts.LayerType) []string {
+	if kinds, ok := e.byLayer[layer]; ok {
+		return kinds
+	}
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	var kinds []string
+	for _, p := range e.policies {
+		for _, w := range p.Weights {
+			if slices.Contains(kinds, w.AnnotationKey) {
+				continue
+			}
+			if p.FetchWeight(w.AnnotationKey, layer, "").Required {
+				kinds = append(kinds, w.AnnotationKey)
+			}
+		}
+	}
+	return kinds
+}
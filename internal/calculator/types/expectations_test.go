@@ -0,0 +1,62 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package types
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+func TestExpectationsKinds(t *testing.T) {
+	optional := false
+	dcfPolicies := []policies.DcfPolicy{
+		{Name: "default", Weights: []policies.Weight{
+			{AnnotationKey: "tpm", Value: 1, Required: true, Layers: map[contracts.LayerType]policies.LayerWeight{
+				contracts.Os: {Required: &optional},
+			}},
+			{AnnotationKey: "tls", Value: 1},
+		}},
+		{Name: "strict", Weights: []policies.Weight{{AnnotationKey: "pki", Value: 1, Required: true}}},
+	}
+	tests := []struct {
+		name     string
+		cfg      config.CollectorInfo
+		layer    contracts.LayerType
+		expected []string
+	}{
+		{"configured", config.CollectorInfo{
+			Expected:   map[contracts.LayerType][]string{contracts.Application: {"tls"}},
+			FromPolicy: true,
+		}, contracts.Application, []string{"tls"}},
+		{"required by any policy", config.CollectorInfo{FromPolicy: true}, contracts.Application, []string{"tpm", "pki"}},
+		{"optional for layer", config.CollectorInfo{FromPolicy: true}, contracts.Os, []string{"pki"}},
+		{"not from policy", config.CollectorInfo{}, contracts.Application, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExpectations(tt.cfg, dcfPolicies)
+			if kinds := e.kinds(tt.layer); !slices.Equal(kinds, tt.expected) {
+				t.Errorf("expected %v, received %v", tt.expected, kinds)
+			}
+			if e.Defined(tt.layer) != (len(tt.expected) > 0) {
+				t.Errorf("expected defined %v", len(tt.expected) > 0)
+			}
+		})
+	}
+}
@@ -19,15 +19,23 @@ import (
 	"time"
 )
 
+// KeySighting tracks when a key was first and most recently received.
+type KeySighting struct {
+	Key       string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Count     int // Count is the number of times the key has been received since it was added
+}
+
 // KeyMap is responsible for managing the list of keys for which we need to calculate scores.
 type KeyMap struct {
-	items map[string]time.Time
+	items map[string]KeySighting
 	mutex sync.Mutex
 }
 
 func NewKeyMap() *KeyMap {
 	km := KeyMap{}
-	km.items = make(map[string]time.Time)
+	km.items = make(map[string]KeySighting)
 	return &km
 }
 
@@ -36,29 +44,50 @@ func (km *KeyMap) Add(key string) {
 	km.mutex.Lock()
 	defer km.mutex.Unlock()
 
-	// Either way -- adding or updating -- the assignment is the same.
-	km.items[key] = time.Now()
+	now := time.Now()
+	item, exists := km.items[key]
+	if !exists {
+		item = KeySighting{Key: key, FirstSeen: now}
+	}
+	item.LastSeen = now
+	item.Count++
+	km.items[key] = item
 }
 
-// Poll will return all of the keys that are ready for processing. This also removes the keys from the internal map.
-func (km *KeyMap) Poll(interval int64) []string {
+// Sightings returns a copy of all keys currently held in the map. The keys are not removed.
+func (km *KeyMap) Sightings() []KeySighting {
 	km.mutex.Lock()
 	defer km.mutex.Unlock()
 
-	// find all relevant items
-	var found []string
-	for k, v := range km.items {
-		if time.Now().Sub(v).Milliseconds() >= interval {
-			found = append(found, k)
-		}
+	result := make([]KeySighting, 0, len(km.items))
+	for _, v := range km.items {
+		result = append(result, v)
 	}
+	return result
+}
 
-	// now delete all relevant items (can't delete during range operation)
-	for _, k := range found {
-		delete(km.items, k)
-	}
-	return found
+// Has indicates whether the key is in the map.
+func (km *KeyMap) Has(key string) bool {
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+
+	_, ok := km.items[key]
+	return ok
 }
 
-// Conceivably at some point there be a Store() method for dealing with in-flight keys if the service gets shut down.
-// Those will simply get dropped for now.
+// Release deletes the keys of the supplied sightings from the map and returns them, unless a key has been received
+// again since it was sighted. Such a key is left in the map, so that its newer annotations are checked before it is
+// released.
+func (km *KeyMap) Release(sightings ...KeySighting) []string {
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+
+	var released []string
+	for _, s := range sightings {
+		if item, ok := km.items[s.Key]; ok && item.Count == s.Count {
+			delete(km.items, s.Key)
+			released = append(released, s.Key)
+		}
+	}
+	return released
+}
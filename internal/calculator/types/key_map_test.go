@@ -0,0 +1,38 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package types
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestKeyMapRelease(t *testing.T) {
+	km := NewKeyMap()
+	km.Add("a")
+	km.Add("b")
+	sightings := km.Sightings()
+	// b is received again after the sightings were read, for example while its annotations were being queried
+	km.Add("b")
+
+	released := km.Release(sightings...)
+	if !slices.Equal(released, []string{"a"}) {
+		t.Errorf("expected only a to be released, received %v", released)
+	}
+	remaining := km.Sightings()
+	if len(remaining) != 1 || remaining[0].Key != "b" || remaining[0].Count != 2 {
+		t.Errorf("expected b to remain with 2 sightings, received %+v", remaining)
+	}
+}
@@ -0,0 +1,76 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// DefaultStack is used when no layer dependencies are configured. App scores are built on the CI/CD score for the tag
+// and the OS score for the host of their annotations, and OS scores on the host score for their tag.
+var DefaultStack = []config.LayerDependency{
+	{Layer: contracts.Application, DependsOn: contracts.CiCd, JoinOn: config.JoinTag},
+	{Layer: contracts.Application, DependsOn: contracts.Os, JoinOn: config.JoinHost},
+	{Layer: contracts.Os, DependsOn: contracts.Host, JoinOn: config.JoinTag},
+}
+
+// LayerGraph holds the lower layers each layer depends on, in the order they were declared.
+type LayerGraph struct {
+	dependencies map[contracts.LayerType][]config.LayerDependency
+}
+
+// NewLayerGraph builds the graph from the configured dependencies, falling back to DefaultStack if there are none. An
+// error is returned if a layer ends up depending on itself.
+func NewLayerGraph(stack []config.LayerDependency) (*LayerGraph, error) {
+	if len(stack) == 0 {
+		stack = DefaultStack
+	}
+
+	g := LayerGraph{dependencies: make(map[contracts.LayerType][]config.LayerDependency)}
+	for _, d := range stack {
+		g.dependencies[d.Layer] = append(g.dependencies[d.Layer], d)
+	}
+
+	for layer := range g.dependencies {
+		if g.reaches(layer, layer, make(map[contracts.LayerType]bool)) {
+			return nil, fmt.Errorf("layer %s depends on itself through the configured stack", layer)
+		}
+	}
+	return &g, nil
+}
+
+// DependsOn returns the dependencies of the layer. A layer without dependencies is scored on its annotations alone.
+func (g *LayerGraph) DependsOn(layer contracts.LayerType) []config.LayerDependency {
+	return g.dependencies[layer]
+}
+
+func (g *LayerGraph) reaches(from contracts.LayerType, target contracts.LayerType, visited map[contracts.LayerType]bool) bool {
+	for _, d := range g.dependencies[from] {
+		if d.DependsOn == target {
+			return true
+		}
+		if visited[d.DependsOn] {
+			continue
+		}
+		visited[d.DependsOn] = true
+		if g.reaches(d.DependsOn, target, visited) {
+			return true
+		}
+	}
+	return false
+}
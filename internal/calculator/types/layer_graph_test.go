@@ -0,0 +1,56 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package types
+
+import (
+	"testing"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+func TestNewLayerGraph(t *testing.T) {
+	tests := []struct {
+		name        string
+		stack       []config.LayerDependency
+		expectError bool
+	}{
+		{"default", nil, false},
+		{"container runtime", append(DefaultStack, config.LayerDependency{
+			Layer: contracts.Application, DependsOn: "container", JoinOn: config.JoinHost}), false},
+		{"cycle", []config.LayerDependency{
+			{Layer: contracts.Application, DependsOn: contracts.Os, JoinOn: config.JoinHost},
+			{Layer: contracts.Os, DependsOn: contracts.Host, JoinOn: config.JoinTag},
+			{Layer: contracts.Host, DependsOn: contracts.Application, JoinOn: config.JoinTag},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewLayerGraph(tt.stack)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(g.DependsOn(contracts.Application)) == 0 || len(g.DependsOn(contracts.CiCd)) != 0 {
+				t.Errorf("unexpected dependencies %+v", g.dependencies)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package types
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+// PolicySet holds the policies the calculator currently scores under. The whole set is replaced at once when the
+// weights are reloaded, so a worker that loaded the set keeps a consistent view while the next one is swapped in.
+type PolicySet struct {
+	current atomic.Pointer[[]policies.DcfPolicy]
+	mutex   sync.Mutex // mutex serializes replacements, loads do not wait on it
+}
+
+func NewPolicySet(dcfPolicies []policies.DcfPolicy) *PolicySet {
+	ps := PolicySet{}
+	ps.current.Store(&dcfPolicies)
+	return &ps
+}
+
+// Load returns the active policies. The result must not be modified.
+func (ps *PolicySet) Load() []policies.DcfPolicy {
+	return *ps.current.Load()
+}
+
+// Replace makes the supplied policies active if their weights, bands or settings differ from the active ones and returns the
+// names of the policies that changed. The order of the weights within a policy is not significant.
+func (ps *PolicySet) Replace(dcfPolicies []policies.DcfPolicy) []string {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	active := make(map[string]policies.DcfPolicy)
+	for _, p := range ps.Load() {
+		active[p.Name] = p
+	}
+
+	var changed []string
+	for _, p := range dcfPolicies {
+		previous, ok := active[p.Name]
+		delete(active, p.Name)
+		if !ok || !sameWeights(weightValues(previous), weightValues(p)) || !reflect.DeepEqual(previous.Bands, p.Bands) ||
+			previous.RequireSignatures != p.RequireSignatures {
+			changed = append(changed, p.Name)
+		}
+	}
+	for name := range active {
+		changed = append(changed, name)
+	}
+
+	if len(changed) > 0 {
+		ps.current.Store(&dcfPolicies)
+	}
+	return changed
+}
+
+func weightValues(p policies.DcfPolicy) map[string]policies.Weight {
+	values := make(map[string]policies.Weight, len(p.Weights))
+	for _, w := range p.Weights {
+		values[w.AnnotationKey] = w
+	}
+	return values
+}
+
+func sameWeights(a map[string]policies.Weight, b map[string]policies.Weight) bool {
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,66 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package types
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
+
+func TestPolicySetReplace(t *testing.T) {
+	active := []policies.DcfPolicy{
+		{Name: "default", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 2}, {AnnotationKey: "tls", Value: 1}}},
+		{Name: "strict", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 5}}},
+	}
+	tests := []struct {
+		name     string
+		next     []policies.DcfPolicy
+		expected []string
+	}{
+		{"reordered weights", []policies.DcfPolicy{
+			{Name: "default", Weights: []policies.Weight{{AnnotationKey: "tls", Value: 1}, {AnnotationKey: "tpm", Value: 2}}},
+			{Name: "strict", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 5}}},
+		}, nil},
+		{"changed value", []policies.DcfPolicy{
+			active[0],
+			{Name: "strict", Weights: []policies.Weight{{AnnotationKey: "tpm", Value: 6}}},
+		}, []string{"strict"}},
+		{"added weight", []policies.DcfPolicy{
+			{Name: "default", Weights: append(slices.Clone(active[0].Weights), policies.Weight{AnnotationKey: "pki", Value: 1})},
+			active[1],
+		}, []string{"default"}},
+		{"removed policy", active[:1], []string{"strict"}},
+		{"changed bands", []policies.DcfPolicy{
+			active[0],
+			{Name: "strict", Weights: active[1].Weights, Bands: []policies.Band{{Name: "trusted", Min: 0.9}}},
+		}, []string{"strict"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := NewPolicySet(active)
+			changed := ps.Replace(tt.next)
+			if !slices.Equal(changed, tt.expected) {
+				t.Errorf("expected changes %v, received %v", tt.expected, changed)
+			}
+			if len(changed) > 0 && len(ps.Load()) != len(tt.next) {
+				t.Errorf("expected the policies to be replaced, received %+v", ps.Load())
+			}
+			if len(changed) == 0 && &ps.Load()[0] != &active[0] {
+				t.Error("expected the active policies to be kept")
+			}
+		})
+	}
+}
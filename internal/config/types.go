@@ -16,6 +16,7 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/config"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
@@ -50,6 +51,130 @@ func (t PolicyType) Validate() bool {
 	return false
 }
 
+type ScoringType string
+
+const (
+	WeightedRatio   ScoringType = "weighted-ratio"
+	WeightedMinimum ScoringType = "weighted-minimum"
+	Bayesian        ScoringType = "bayesian"
+	GeometricMean   ScoringType = "geometric-mean"
+)
+
+func (t ScoringType) Validate() bool {
+	if t == WeightedRatio || t == WeightedMinimum || t == Bayesian || t == GeometricMean {
+		return true
+	}
+	return false
+}
+
+type QueueType string
+
+const (
+	MemoryQueue QueueType = "memory"
+	FileQueue   QueueType = "file"
+)
+
+func (t QueueType) Validate() bool {
+	if t == MemoryQueue || t == FileQueue {
+		return true
+	}
+	return false
+}
+
+type DecayType string
+
+const (
+	NoDecay          DecayType = "none"
+	LinearDecay      DecayType = "linear"
+	ExponentialDecay DecayType = "exponential"
+	CutoffDecay      DecayType = "cutoff"
+)
+
+func (t DecayType) Validate() bool {
+	if t == NoDecay || t == LinearDecay || t == ExponentialDecay || t == CutoffDecay {
+		return true
+	}
+	return false
+}
+
+type PropagationType string
+
+const (
+	NoPropagation      PropagationType = "none"
+	MinimumPropagation PropagationType = "min"
+	ProductPropagation PropagationType = "product"
+	DecayPropagation   PropagationType = "decay"
+)
+
+func (t PropagationType) Validate() bool {
+	if t == NoPropagation || t == MinimumPropagation || t == ProductPropagation || t == DecayPropagation {
+		return true
+	}
+	return false
+}
+
+type MissingAction string
+
+const (
+	IgnoreMissing   MissingAction = "ignore"
+	MultiplyMissing MissingAction = "multiplier"
+	ZeroMissing     MissingAction = "zero"
+	DeferMissing    MissingAction = "defer"
+)
+
+func (t MissingAction) Validate() bool {
+	if t == IgnoreMissing || t == MultiplyMissing || t == ZeroMissing || t == DeferMissing {
+		return true
+	}
+	return false
+}
+
+type JoinField string
+
+const (
+	JoinTag  JoinField = "tag"
+	JoinHost JoinField = "host"
+)
+
+func (t JoinField) Validate() bool {
+	if t == JoinTag || t == JoinHost {
+		return true
+	}
+	return false
+}
+
+// VerificationAction is what the subscriber does with an annotation whose signature cannot be verified
+type VerificationAction string
+
+const (
+	RecordUnverified     VerificationAction = "record"
+	RejectUnverified     VerificationAction = "reject"
+	QuarantineUnverified VerificationAction = "quarantine"
+)
+
+func (t VerificationAction) Validate() bool {
+	if t == RecordUnverified || t == RejectUnverified || t == QuarantineUnverified {
+		return true
+	}
+	return false
+}
+
+// UnrecognizedAction is what the subscriber does with a message whose SDK action it does not recognize
+type UnrecognizedAction string
+
+const (
+	IgnoreUnrecognized     UnrecognizedAction = "ignore"
+	LogUnrecognized        UnrecognizedAction = "log"
+	DeadLetterUnrecognized UnrecognizedAction = "deadletter"
+)
+
+func (t UnrecognizedAction) Validate() bool {
+	if t == IgnoreUnrecognized || t == LogUnrecognized || t == DeadLetterUnrecognized {
+		return true
+	}
+	return false
+}
+
 type ArangoConfig struct {
 	DatabaseName string             `json:"databaseName,omitempty"`
 	Edges        []EdgeInfo         `json:"edges,omitempty"`
@@ -123,15 +248,41 @@ type PolicyInfo struct {
 	Config interface{} `json:"config,omitempty"`
 }
 
+// OpenPolicyConfig locates the OPA server and the rules queried on it. If a decision path is given, the calculator asks
+// for a decision on every score, passing the full context of the annotations. Timeout and RetryDelay are in
+// milliseconds. Decisions are cached for CacheTTL milliseconds, where zero disables the cache. Weights are never
+// cached.
 type OpenPolicyConfig struct {
-	Provider    config.ServiceInfo `json:"provider,omitempty"`
-	WeightsInfo OpaWeightsInfo     `json:"weights,omitempty"`
+	Provider     config.ServiceInfo `json:"provider,omitempty"`
+	WeightsInfo  OpaWeightsInfo     `json:"weights,omitempty"`
+	BandsInfo    OpaBandsInfo       `json:"bands,omitempty"`
+	DecisionInfo OpaDecisionInfo    `json:"decision,omitempty"`
+	Timeout      int64              `json:"timeout,omitempty"`
+	Retries      int                `json:"retries,omitempty"`
+	RetryDelay   int64              `json:"retryDelay,omitempty"`
+	CacheTTL     int64              `json:"cacheTtl,omitempty"`
 }
 
 type OpaWeightsInfo struct {
 	Path string `json:"path,omitempty"`
 }
 
+// OpaBandsInfo locates the rule returning the confidence bands of a class. If no path is given, the default bands are
+// used.
+type OpaBandsInfo struct {
+	Path string `json:"path,omitempty"`
+}
+
+type OpaDecisionInfo struct {
+	Path string `json:"path,omitempty"`
+}
+
+// Decides indicates whether the policy is asked for a decision on every score rather than just for its weights.
+func (p PolicyInfo) Decides() bool {
+	cfg, ok := p.Config.(OpenPolicyConfig)
+	return ok && cfg.DecisionInfo.Path != ""
+}
+
 type LocalPolicyConfig struct {
 	WeightsInfo []policies.DcfPolicy `json:"weights,omitempty"`
 }
@@ -193,6 +344,16 @@ func (p *LocalPolicyConfig) UnmarshalJSON(data []byte) (err error) {
 			if !key.Validate() {
 				return fmt.Errorf("invalid AnnotatorType value provided %s", key)
 			}
+			for layer := range weight.Layers {
+				if !layer.Validate() {
+					return fmt.Errorf("invalid LayerType value provided %s for %s", layer, key)
+				}
+			}
+		}
+		for _, band := range info.Bands {
+			if band.Name == "" || band.Min < 0 || band.Min > 1 {
+				return fmt.Errorf("invalid band %+v for %s, a name and a minimum from 0 to 1 are required", band, info.Name)
+			}
 		}
 	}
 
@@ -200,8 +361,348 @@ func (p *LocalPolicyConfig) UnmarshalJSON(data []byte) (err error) {
 	return nil
 }
 
+// ScoringInfo selects the strategy used by the calculator to reduce a set of annotations into a confidence score.
+// If omitted from the config, the weighted ratio strategy is used.
+type ScoringInfo struct {
+	Type   ScoringType `json:"type,omitempty"`
+	Config interface{} `json:"config,omitempty"`
+}
+
+// BayesianConfig defines the parameters of the beta distribution used as the prior for the bayesian strategy.
+type BayesianConfig struct {
+	Alpha float64 `json:"alpha,omitempty"` // Alpha is the prior weight of satisfied annotations
+	Beta  float64 `json:"beta,omitempty"`  // Beta is the prior weight of unsatisfied annotations
+}
+
+func (s *ScoringInfo) UnmarshalJSON(data []byte) (err error) {
+	type Alias struct {
+		Type ScoringType
+	}
+	a := Alias{}
+	if err = json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	if a.Type == "" {
+		a.Type = WeightedRatio
+	}
+	if !a.Type.Validate() {
+		return fmt.Errorf("invalid ScoringType value provided %s", a.Type)
+	}
+	if a.Type == Bayesian {
+		type bayesianAlias struct {
+			Config BayesianConfig `json:"config,omitempty"`
+		}
+		i := bayesianAlias{}
+		if err = json.Unmarshal(data, &i); err != nil {
+			return err
+		}
+		s.Config = i.Config
+	}
+	s.Type = a.Type
+	return nil
+}
+
+// DecayInfo selects how the confidence of a lower layer score is reduced according to its age when it is used to
+// calculate the score of a layer above it. If omitted from the config, lower layer scores do not decay.
+type DecayInfo struct {
+	Type   DecayType   `json:"type,omitempty"`
+	Config interface{} `json:"config,omitempty"`
+}
+
+// LinearDecayConfig reduces confidence linearly from its full value to zero at MaxAge milliseconds.
+type LinearDecayConfig struct {
+	MaxAge int64 `json:"maxAge,omitempty"`
+}
+
+// ExponentialDecayConfig halves confidence every HalfLife milliseconds.
+type ExponentialDecayConfig struct {
+	HalfLife int64 `json:"halfLife,omitempty"`
+}
+
+// CutoffDecayConfig keeps confidence at its full value until MaxAge milliseconds, after which it is zero.
+type CutoffDecayConfig struct {
+	MaxAge int64 `json:"maxAge,omitempty"`
+}
+
+func (d *DecayInfo) UnmarshalJSON(data []byte) (err error) {
+	type Alias struct {
+		Type DecayType
+	}
+	a := Alias{}
+	if err = json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	if a.Type == "" {
+		a.Type = NoDecay
+	}
+	if !a.Type.Validate() {
+		return fmt.Errorf("invalid DecayType value provided %s", a.Type)
+	}
+	switch a.Type {
+	case LinearDecay:
+		type linearAlias struct {
+			Config LinearDecayConfig `json:"config,omitempty"`
+		}
+		i := linearAlias{}
+		if err = json.Unmarshal(data, &i); err != nil {
+			return err
+		}
+		if i.Config.MaxAge <= 0 {
+			return errors.New("maxAge must be greater than zero for linear decay")
+		}
+		d.Config = i.Config
+	case ExponentialDecay:
+		type exponentialAlias struct {
+			Config ExponentialDecayConfig `json:"config,omitempty"`
+		}
+		i := exponentialAlias{}
+		if err = json.Unmarshal(data, &i); err != nil {
+			return err
+		}
+		if i.Config.HalfLife <= 0 {
+			return errors.New("halfLife must be greater than zero for exponential decay")
+		}
+		d.Config = i.Config
+	case CutoffDecay:
+		type cutoffAlias struct {
+			Config CutoffDecayConfig `json:"config,omitempty"`
+		}
+		i := cutoffAlias{}
+		if err = json.Unmarshal(data, &i); err != nil {
+			return err
+		}
+		if i.Config.MaxAge <= 0 {
+			return errors.New("maxAge must be greater than zero for cutoff decay")
+		}
+		d.Config = i.Config
+	}
+	d.Type = a.Type
+	return nil
+}
+
+// QueueInfo defines where the calculator keeps track of the keys it has received but not yet scored. If omitted from
+// the config, keys are only held in memory and are lost on shutdown.
+type QueueInfo struct {
+	Type   QueueType   `json:"type,omitempty"`
+	Config interface{} `json:"config,omitempty"`
+}
+
+// FileQueueConfig provides the location of the append log used to persist outstanding keys.
+type FileQueueConfig struct {
+	Path string `json:"path,omitempty"`
+}
+
+func (q *QueueInfo) UnmarshalJSON(data []byte) (err error) {
+	type Alias struct {
+		Type QueueType
+	}
+	a := Alias{}
+	if err = json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	if a.Type == "" {
+		a.Type = MemoryQueue
+	}
+	if !a.Type.Validate() {
+		return fmt.Errorf("invalid QueueType value provided %s", a.Type)
+	}
+	if a.Type == FileQueue {
+		type fileAlias struct {
+			Config FileQueueConfig `json:"config,omitempty"`
+		}
+		i := fileAlias{}
+		if err = json.Unmarshal(data, &i); err != nil {
+			return err
+		}
+		if i.Config.Path == "" {
+			return errors.New("path is required for the file queue")
+		}
+		q.Config = i.Config
+	}
+	q.Type = a.Type
+	return nil
+}
+
+// CollectorInfo defines when the calculator considers the annotations received for a key complete enough to score.
+// Layers without any expected annotation kinds are scored once no new annotations have arrived for a short interval.
+type CollectorInfo struct {
+	MaxWait    int64                            `json:"maxWait,omitempty"`    // MaxWait is the number of milliseconds to wait for the expected annotations before scoring anyway
+	Expected   map[contracts.LayerType][]string `json:"expected,omitempty"`   // Expected lists the annotation kinds expected for each layer
+	FromPolicy bool                             `json:"fromPolicy,omitempty"` // FromPolicy derives the expected kinds from the active policy for layers not listed in Expected
+}
+
+// PropagationInfo selects how the confidence of the data a piece of data was mutated from is folded into its own
+// confidence. If omitted from the config, lineage is ignored.
+type PropagationInfo struct {
+	Type   PropagationType `json:"type,omitempty"`
+	Config interface{}     `json:"config,omitempty"`
+}
+
+// DecayPropagationConfig multiplies the confidence of a parent by Factor, between 0 and 1, for each hop of lineage.
+type DecayPropagationConfig struct {
+	Factor float64 `json:"factor,omitempty"`
+}
+
+func (p *PropagationInfo) UnmarshalJSON(data []byte) (err error) {
+	type Alias struct {
+		Type PropagationType
+	}
+	a := Alias{}
+	if err = json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	if a.Type == "" {
+		a.Type = NoPropagation
+	}
+	if !a.Type.Validate() {
+		return fmt.Errorf("invalid PropagationType value provided %s", a.Type)
+	}
+	if a.Type == DecayPropagation {
+		type decayAlias struct {
+			Config DecayPropagationConfig `json:"config,omitempty"`
+		}
+		i := decayAlias{}
+		if err = json.Unmarshal(data, &i); err != nil {
+			return err
+		}
+		if i.Config.Factor <= 0 || i.Config.Factor > 1 {
+			return errors.New("factor must be greater than zero and at most 1 for decay propagation")
+		}
+		p.Config = i.Config
+	}
+	p.Type = a.Type
+	return nil
+}
+
+// MissingInfo decides what happens when an annotation's tag or host has no score in the lower layer it depends on.
+// If omitted from the config, the missing score is ignored.
+type MissingInfo struct {
+	Action     MissingAction `json:"action,omitempty"`
+	Multiplier float64       `json:"multiplier,omitempty"` // Multiplier stands in for the missing confidence when Action is "multiplier"
+}
+
+func (m *MissingInfo) UnmarshalJSON(data []byte) (err error) {
+	type Alias MissingInfo
+	a := Alias{}
+	if err = json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	if a.Action == "" {
+		a.Action = IgnoreMissing
+	}
+	if !a.Action.Validate() {
+		return fmt.Errorf("invalid MissingAction value provided %s", a.Action)
+	}
+	if a.Action == MultiplyMissing && (a.Multiplier < 0 || a.Multiplier > 1) {
+		return errors.New("multiplier must be between 0 and 1")
+	}
+	*m = MissingInfo(a)
+	return nil
+}
+
+// LayerDependency declares that the scores of a layer are built on the scores of a lower layer. The lower layer score
+// used for an annotation is the one whose tag matches the JoinOn field of the annotation.
+type LayerDependency struct {
+	Layer     contracts.LayerType `json:"layer,omitempty"`
+	DependsOn contracts.LayerType `json:"dependsOn,omitempty"`
+	JoinOn    JoinField           `json:"joinOn,omitempty"`
+}
+
+func (d *LayerDependency) UnmarshalJSON(data []byte) (err error) {
+	type Alias LayerDependency
+	a := Alias{}
+	if err = json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	if a.Layer == "" || a.DependsOn == "" {
+		return errors.New("layer and dependsOn are required for a layer dependency")
+	}
+	if a.Layer == a.DependsOn {
+		return fmt.Errorf("layer %s cannot depend on itself", a.Layer)
+	}
+	if !a.JoinOn.Validate() {
+		return fmt.Errorf("invalid JoinField value provided %s", a.JoinOn)
+	}
+	*d = LayerDependency(a)
+	return nil
+}
+
+// CascadeInfo controls whether a new lower layer score causes the scores of the layers depending on it to be
+// recalculated. FanOut limits how many dependent scores are recalculated for each new score.
+type CascadeInfo struct {
+	Enabled bool `json:"enabled,omitempty"`
+	FanOut  int  `json:"fanOut,omitempty"`
+}
+
+// ReloadInfo controls when the calculator refreshes the weights of its policies without restarting. Interval is the
+// time in milliseconds between refreshes, where zero disables them. If Watch is set, the config file is checked for
+// changes to local policies. Every message received on the Control stream, if defined, also causes a refresh.
+type ReloadInfo struct {
+	Interval int64              `json:"interval,omitempty"`
+	Watch    bool               `json:"watch,omitempty"`
+	Control  *config.StreamInfo `json:"control,omitempty"`
+}
+
 // PubSubInfo encapsulates endpoint definitions for publishing and subscribing to the relevant platform providers.
 type PubSubInfo struct {
 	Publish   config.StreamInfo `json:"publisher,omitempty"`  //Defines the publisher endpoint
 	Subscribe config.StreamInfo `json:"subscriber,omitempty"` //Defines the subscriber endpoint
 }
+
+// VerificationInfo lists the public keys the subscriber verifies annotation signatures against. An annotation is valid
+// if any of the keys verifies its signature. Action says what happens to the annotations that are not valid; by
+// default they are recorded as such.
+type VerificationInfo struct {
+	Keys   []config.KeyInfo   `json:"keys,omitempty"`
+	Action VerificationAction `json:"action,omitempty"`
+}
+
+func (v *VerificationInfo) UnmarshalJSON(data []byte) (err error) {
+	type Alias VerificationInfo
+	a := Alias{}
+	if err = json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	if a.Action == "" {
+		a.Action = RecordUnverified
+	}
+	if !a.Action.Validate() {
+		return fmt.Errorf("invalid VerificationAction value provided %s", a.Action)
+	}
+	for _, k := range a.Keys {
+		if k.Path == "" {
+			return fmt.Errorf("a path is required for the %s public key", k.Type)
+		}
+	}
+	*v = VerificationInfo(a)
+	return nil
+}
+
+// UnrecognizedInfo decides what the subscriber does with a message whose SDK action it does not recognize, such as one
+// added to the SDK after the subscriber was built. If omitted from the config, the message is ignored.
+type UnrecognizedInfo struct {
+	Action UnrecognizedAction `json:"action,omitempty"`
+}
+
+func (u *UnrecognizedInfo) UnmarshalJSON(data []byte) (err error) {
+	type Alias UnrecognizedInfo
+	a := Alias{}
+	if err = json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	if a.Action == "" {
+		a.Action = IgnoreUnrecognized
+	}
+	if !a.Action.Validate() {
+		return fmt.Errorf("invalid UnrecognizedAction value provided %s", a.Action)
+	}
+	*u = UnrecognizedInfo(a)
+	return nil
+}
+
+// DeadLetterInfo controls whether messages that cannot be decoded or processed are kept in the "deadletters"
+// collection of the application's database. If a Stream is defined, each dead letter is published on it as well.
+type DeadLetterInfo struct {
+	Enabled bool               `json:"enabled,omitempty"`
+	Stream  *config.StreamInfo `json:"stream,omitempty"`
+}
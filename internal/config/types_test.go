@@ -0,0 +1,47 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUnrecognizedInfoUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected UnrecognizedAction
+		fails    bool
+	}{
+		{"omitted", `{}`, IgnoreUnrecognized, false},
+		{"ignore", `{"action":"ignore"}`, IgnoreUnrecognized, false},
+		{"log", `{"action":"log"}`, LogUnrecognized, false},
+		{"deadletter", `{"action":"deadletter"}`, DeadLetterUnrecognized, false},
+		{"invalid", `{"action":"drop"}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info UnrecognizedInfo
+			err := json.Unmarshal([]byte(tt.value), &info)
+			if (err != nil) != tt.fails {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if info.Action != tt.expected {
+				t.Errorf("expected %s, received %s", tt.expected, info.Action)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/arangodb/go-driver"
 	"github.com/arangodb/go-driver/http"
@@ -26,6 +27,9 @@ import (
 	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
 )
 
+// ErrScoreNotFound is returned when a layer has not yet been scored for a tag or host.
+var ErrScoreNotFound = errors.New("no score found")
+
 // TODO: This Client is shared between the Populator and Populator-API. Meanwhile both the Subscriber and Calculator ALSO
 // have their own respective Arango clients. Need to look at how this sprawl can be refactored to reduce duplication
 // and maintenance overhead.
@@ -77,14 +81,18 @@ func NewArangoClient(
 	return &client, nil
 }
 
-func (c *ArangoClient) QueryScore(ctx context.Context, key string) (documents.Score, error) {
+// QueryScore returns the current score for a key calculated under the policy. If no policy is given, the most recent
+// current score under any policy is returned.
+func (c *ArangoClient) QueryScore(ctx context.Context, key string, policy string) (documents.Score, error) {
 	db, err := c.instance.Database(ctx, c.cfg.DatabaseName)
 	if err != nil {
 		return documents.Score{}, err
 	}
-	query := "FOR s in scores FILTER s.dataRef == @key RETURN s"
+	query := `FOR s in scores FILTER s.dataRef == @key AND s.current != false AND (@policy == null OR s.policy == @policy)
+				SORT s.timestamp DESC LIMIT 1 RETURN s`
 	bindVars := map[string]interface{}{
-		"key": key,
+		"key":    key,
+		"policy": policyFilter(policy),
 	}
 	cursor, err := db.Query(ctx, query, bindVars)
 	if err != nil {
@@ -105,6 +113,39 @@ func (c *ArangoClient) QueryScore(ctx context.Context, key string) (documents.Sc
 	return score, nil
 }
 
+// QueryScoreHistory returns every version of the score for a key, newest first. If no policy is given, the versions
+// calculated under every policy are returned.
+func (c *ArangoClient) QueryScoreHistory(ctx context.Context, key string, policy string) ([]documents.Score, error) {
+	db, err := c.instance.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+	query := `FOR s in scores FILTER s.dataRef == @key AND (@policy == null OR s.policy == @policy)
+				SORT s.policy, s.version DESC, s.timestamp DESC RETURN s`
+	bindVars := map[string]interface{}{
+		"key":    key,
+		"policy": policyFilter(policy),
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var scores []documents.Score
+	for {
+		var score documents.Score
+		_, err := cursor.ReadDocument(ctx, &score)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		scores = append(scores, score)
+	}
+	return scores, nil
+}
+
 func (c *ArangoClient) QueryAnnotations(
 	ctx context.Context,
 	key string,
@@ -120,7 +161,7 @@ func (c *ArangoClient) QueryAnnotations(
 	// that have a tag included in that array are returned by the query. This will work
 	// with all layer annotations.
 	query := `
-		FOR score IN scores FILTER score.dataRef == @key
+		FOR score IN scores FILTER score.dataRef == @key AND score.current != false
 			FOR v, e, p IN 1..1 ANY score._id GRAPH @graph
 			FILTER CONTAINS(e._id, @stack)
 			LET tags = v.tag
@@ -163,6 +204,7 @@ func (c *ArangoClient) QueryScoreByLayer(
 	ctx context.Context,
 	key string,
 	layer contracts.LayerType,
+	policy string,
 ) ([]documents.Score, error) {
 	db, err := c.instance.Database(ctx, c.cfg.DatabaseName)
 	if err != nil {
@@ -172,25 +214,28 @@ func (c *ArangoClient) QueryScoreByLayer(
 	var query string
 	switch layer {
 	case contracts.Application:
-		query = `FOR s IN scores FILTER s.dataRef == @key AND s.layer == @layer RETURN [s]`
+		query = `FOR s IN scores FILTER s.dataRef == @key AND s.layer == @layer AND s.current != false
+				AND (@policy == null OR s.policy == @policy) RETURN [s]`
 	case contracts.CiCd:
-		query = `FOR appScore IN scores FILTER appScore.dataRef == @key 
+		query = `FOR appScore IN scores FILTER appScore.dataRef == @key AND appScore.current != false
+				AND (@policy == null OR appScore.policy == @policy)
 				LET cicdScore = (
 					FOR s IN scores FILTER 
-					s.layer == @layer AND s.tag ANY IN appScore.tag 
+					s.layer == @layer AND s.tag ANY IN appScore.tag AND s.current != false AND s.policy == appScore.policy
 					RETURN s 
 				)
 				RETURN cicdScore `
 	case contracts.Os, contracts.Host:
 		query = `FOR a in annotations FILTER a.dataRef == @key LIMIT 1
 				LET scores = (FOR s IN scores FILTER s.layer == @layer AND
-				        a.host IN s.tag RETURN s)
+				        a.host IN s.tag AND s.current != false AND (@policy == null OR s.policy == @policy) RETURN s)
 				RETURN scores`
 
 	}
 	bindVars := map[string]interface{}{
-		"key":   key,
-		"layer": layer,
+		"key":    key,
+		"layer":  layer,
+		"policy": policyFilter(policy),
 	}
 	cursor, err := db.Query(ctx, query, bindVars)
 	if err != nil {
@@ -249,3 +294,137 @@ func (c *ArangoClient) FetchHosts(ctx context.Context) ([]string, error) {
 
 	return hosts, nil
 }
+
+// QueryDataAnnotations returns only the annotations made directly on the data item, unlike QueryAnnotations which also
+// includes the annotations of the lower layers.
+func (c *ArangoClient) QueryDataAnnotations(ctx context.Context, key string) ([]documents.Annotation, error) {
+	db, err := c.instance.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+	query := "FOR a IN annotations FILTER a.dataRef == @key RETURN a"
+	bindVars := map[string]interface{}{
+		"key": key,
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var annotations []documents.Annotation
+	for {
+		var a documents.Annotation
+		_, err := cursor.ReadDocument(ctx, &a)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, nil
+}
+
+// QueryScoreByTag returns the most recent current score of the layer for the tag, calculated under the policy if one is
+// given.
+func (c *ArangoClient) QueryScoreByTag(
+	ctx context.Context,
+	tag string,
+	layer contracts.LayerType,
+	policy string,
+) (documents.Score, error) {
+	db, err := c.instance.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return documents.Score{}, err
+	}
+	return QueryScoreByTag(ctx, db, tag, layer, policy)
+}
+
+// QueryScoreByTag returns the current score of the layer for the tag or host value under the policy from the database,
+// or ErrScoreNotFound if there is none. If no policy is given, the most recent current score under any policy is
+// returned. It is shared by the clients of the calculator and the populator API so that both find the same lower layer
+// scores.
+func QueryScoreByTag(
+	ctx context.Context,
+	db driver.Database,
+	tag string,
+	layer contracts.LayerType,
+	policy string,
+) (documents.Score, error) {
+	query := `FOR s in scores
+				FILTER @tag IN s.tag[*] AND s.layer == @layer AND (@policy == null OR s.policy == @policy)
+				FILTER s.confidence != null AND s.current != false
+				SORT s.timestamp DESC
+				LIMIT 1
+				RETURN s`
+	bindVars := map[string]interface{}{
+		"tag":    tag,
+		"layer":  layer,
+		"policy": policyFilter(policy),
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return documents.Score{}, err
+	}
+	defer cursor.Close()
+
+	// There should only be one document returned here
+	var score documents.Score
+	found := false
+	for {
+		_, err := cursor.ReadDocument(ctx, &score)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return documents.Score{}, err
+		}
+		found = true
+	}
+	if !found {
+		return documents.Score{}, fmt.Errorf("%w for tag: %s", ErrScoreNotFound, tag)
+	}
+	return score, nil
+}
+
+// QueryPolicyHistory returns every stored version of the named policy, the most recently activated first.
+func (c *ArangoClient) QueryPolicyHistory(ctx context.Context, name string) ([]documents.Policy, error) {
+	db, err := c.instance.Database(ctx, c.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+	// Versions stored before activation was recorded fall back to the time they were first stored
+	query := `FOR p IN @@policies FILTER p.classifier == @name
+				SORT NOT_NULL(p.activated, p.timestamp) DESC
+				RETURN p`
+	bindVars := map[string]interface{}{
+		"@policies": documents.VertexPolicies,
+		"name":      name,
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var versions []documents.Policy
+	for {
+		var p documents.Policy
+		_, err := cursor.ReadDocument(ctx, &p)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		versions = append(versions, p)
+	}
+	return versions, nil
+}
+
+// policyFilter binds an empty policy as null so the queries match scores calculated under any policy.
+func policyFilter(policy string) interface{} {
+	if policy == "" {
+		return nil
+	}
+	return policy
+}
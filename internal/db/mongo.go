@@ -78,13 +78,18 @@ func (mp *MongoProvider) FetchById(ctx context.Context, id string) (models.Mongo
 	return result, err
 }
 
-func (mp *MongoProvider) QueryMostRecent(ctx context.Context, count int) ([]models.MongoRecord, error) {
+// QueryMostRecent returns the most recent records, limited to those labeled with the confidence band if one is given.
+func (mp *MongoProvider) QueryMostRecent(ctx context.Context, count int, band string) ([]models.MongoRecord, error) {
 	var results []models.MongoRecord
 	coll := mp.instance.Database(mp.cfg.DbName).Collection(mp.cfg.Collection)
 	findOptions := options.Find()
 	findOptions.SetLimit(int64(count))
 	findOptions.SetSort(bson.D{{Key: "timestampiso", Value: -1}})
-	cursor, err := coll.Find(ctx, bson.D{}, findOptions)
+	filter := bson.D{}
+	if band != "" {
+		filter = bson.D{{Key: "band", Value: band}}
+	}
+	cursor, err := coll.Find(ctx, filter, findOptions)
 	if err != nil {
 		return results, err
 	}
@@ -0,0 +1,136 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	SdkInterfaces "github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/factories"
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/msg"
+)
+
+// The sources recorded on dead letters, which select the stream they are replayed on
+const (
+	SourceCalculator string = "calculator"
+	SourceSubscriber string = "subscriber"
+)
+
+// DeadLetterType is the message type of a dead letter published on the dead letter stream
+const DeadLetterType string = "DeadLetter"
+
+// DeadLetters records the messages an application could not process.
+type DeadLetters struct {
+	logger    SdkInterfaces.Logger
+	publisher interfaces.Publisher
+	source    string
+	store     LetterStore
+}
+
+// NewDeadLetters returns nil if dead letters are not enabled, in which case failed messages are only logged.
+func NewDeadLetters(
+	info config.DeadLetterInfo,
+	dbConfig config.DatabaseInfo,
+	source string,
+	logger SdkInterfaces.Logger,
+) (*DeadLetters, error) {
+	if !info.Enabled {
+		return nil, nil
+	}
+	store, err := NewStore(dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	var publisher interfaces.Publisher
+	if info.Stream != nil {
+		publisher, err = factories.NewPublisher(*info.Stream)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewDeadLettersWithStore(store, publisher, source, logger), nil
+}
+
+// NewDeadLettersWithStore records dead letters in the given store. The publisher is optional, and if it is set every
+// dead letter is also published on it.
+func NewDeadLettersWithStore(
+	store LetterStore,
+	publisher interfaces.Publisher,
+	source string,
+	logger SdkInterfaces.Logger,
+) *DeadLetters {
+	return &DeadLetters{
+		logger:    logger,
+		publisher: publisher,
+		source:    source,
+		store:     store,
+	}
+}
+
+func (d *DeadLetters) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bool {
+	err := d.store.Init(ctx)
+	if err != nil {
+		d.logger.Error(err.Error())
+		return false
+	}
+
+	wg.Add(1)
+	go func() { // Graceful shutdown
+		defer wg.Done()
+
+		<-ctx.Done()
+		if d.publisher != nil {
+			d.publisher.Close()
+		}
+		d.logger.Write(slog.LevelInfo, "shutdown received")
+	}()
+	return true
+}
+
+// Record keeps the payload along with the error that stopped it from being processed. The topic is optional. Failures
+// to record the message are logged since there is nowhere else to send them.
+func (d *DeadLetters) Record(ctx context.Context, topic string, payload []byte, cause error) {
+	letter := documents.DeadLetter{
+		Key:       documents.NewULID(),
+		Source:    d.source,
+		Topic:     topic,
+		Payload:   payload,
+		Error:     cause.Error(),
+		Timestamp: time.Now(),
+	}
+	err := d.store.Add(ctx, letter)
+	if err != nil {
+		d.logger.Error(fmt.Sprintf("failed to dead-letter message: %s", err.Error()))
+		return
+	}
+	d.logger.Write(slog.LevelDebug, "message dead-lettered "+letter.Key.String())
+
+	if d.publisher == nil {
+		return
+	}
+	b, _ := json.Marshal(letter)
+	err = d.publisher.Publish(ctx, msg.PublishWrapper{MessageType: DeadLetterType, Content: b})
+	if err != nil {
+		d.logger.Error(err.Error())
+	}
+}
@@ -0,0 +1,143 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	SdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/factories"
+	SdkInterfaces "github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/msg"
+)
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		name      string
+		stream    bool
+		failing   error
+		stored    bool
+		published bool
+	}{
+		{"stored", false, nil, true, false},
+		{"stored and published", true, nil, true, true},
+		{"store failure", true, errors.New("unavailable"), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{failing: tt.failing}
+			publisher := &fakePublisher{}
+			d := NewDeadLettersWithStore(store, nil, SourceSubscriber, newTestLogger())
+			if tt.stream {
+				d = NewDeadLettersWithStore(store, publisher, SourceSubscriber, newTestLogger())
+			}
+			d.Record(context.Background(), "topic", []byte("{"), errors.New("unexpected end of JSON input"))
+
+			if (len(store.letters) == 1) != tt.stored {
+				t.Fatalf("expected stored %v, received %d dead letters", tt.stored, len(store.letters))
+			}
+			if tt.stored {
+				letter := store.letters[0]
+				if letter.Source != SourceSubscriber || letter.Topic != "topic" || string(letter.Payload) != "{" ||
+					letter.Error != "unexpected end of JSON input" {
+					t.Errorf("unexpected dead letter %+v", letter)
+				}
+			}
+			if (len(publisher.published) == 1) != tt.published {
+				t.Fatalf("expected published %v, received %d messages", tt.published, len(publisher.published))
+			}
+			if tt.published {
+				wrap := publisher.published[0]
+				content, _ := wrap.Content.([]byte)
+				var letter documents.DeadLetter
+				if err := json.Unmarshal(content, &letter); err != nil {
+					t.Fatal(err)
+				}
+				if wrap.MessageType != DeadLetterType || letter.Key != store.letters[0].Key {
+					t.Errorf("expected the stored dead letter to be published, received %s %+v", wrap.MessageType,
+						letter)
+				}
+			}
+		})
+	}
+}
+
+// fakeStore keeps dead letters in memory.
+type fakeStore struct {
+	failing error
+	letters []documents.DeadLetter
+	mutex   sync.Mutex
+}
+
+func (s *fakeStore) Init(ctx context.Context) error {
+	return nil
+}
+
+func (s *fakeStore) Add(ctx context.Context, letter documents.DeadLetter) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failing != nil {
+		return s.failing
+	}
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+func (s *fakeStore) MarkReplayed(ctx context.Context, key string, replayed time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, letter := range s.letters {
+		if letter.Key.String() == key {
+			s.letters[i].Replayed = &replayed
+			return nil
+		}
+	}
+	return errors.New("dead letter not found " + key)
+}
+
+// fakePublisher keeps the messages it is given.
+type fakePublisher struct {
+	failing   error
+	published []msg.PublishWrapper
+	raw       [][]byte
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, message msg.PublishWrapper) error {
+	p.published = append(p.published, message)
+	return p.failing
+}
+
+func (p *fakePublisher) PublishRaw(ctx context.Context, payload []byte) error {
+	if p.failing != nil {
+		return p.failing
+	}
+	p.raw = append(p.raw, payload)
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	return nil
+}
+
+func newTestLogger() SdkInterfaces.Logger {
+	return factories.NewLogger(SdkConfig.LoggingInfo{MinLogLevel: slog.LevelError})
+}
@@ -0,0 +1,31 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deadletter
+
+import (
+	"context"
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// LetterStore persists dead letters. It is implemented by Store.
+type LetterStore interface {
+	// Init prepares the store, for example by creating its collection
+	Init(ctx context.Context) error
+	Add(ctx context.Context, letter documents.DeadLetter) error
+	// MarkReplayed records when the dead letter with the given key was replayed
+	MarkReplayed(ctx context.Context, key string, replayed time.Time) error
+}
@@ -0,0 +1,68 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deadletter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	SdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/factories"
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// Replayer publishes dead letters again, exactly as they were received, on the stream of the application that
+// received them.
+type Replayer struct {
+	publishers map[string]interfaces.Publisher
+	store      LetterStore
+}
+
+func NewReplayer(streams map[string]SdkConfig.StreamInfo, store LetterStore) (*Replayer, error) {
+	r := Replayer{
+		publishers: make(map[string]interfaces.Publisher),
+		store:      store,
+	}
+	for source, stream := range streams {
+		p, err := factories.NewPublisher(stream)
+		if err != nil {
+			return nil, err
+		}
+		r.publishers[source] = p
+	}
+	return &r, nil
+}
+
+// Replay publishes the dead letter and records when it was replayed. The dead letter is kept, so if it fails again a
+// new dead letter is recorded alongside it.
+func (r *Replayer) Replay(ctx context.Context, letter documents.DeadLetter) error {
+	p, ok := r.publishers[letter.Source]
+	if !ok {
+		return fmt.Errorf("no replay stream is configured for source %s", letter.Source)
+	}
+	err := p.PublishRaw(ctx, letter.Payload)
+	if err != nil {
+		return err
+	}
+	return r.store.MarkReplayed(ctx, letter.Key.String(), time.Now())
+}
+
+func (r *Replayer) Close() {
+	for _, p := range r.publishers {
+		p.Close()
+	}
+}
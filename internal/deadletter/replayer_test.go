@@ -0,0 +1,67 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/interfaces"
+)
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		failing  error
+		replayed bool
+		fails    bool
+	}{
+		{"replayed", SourceSubscriber, nil, true, false},
+		{"no stream for source", SourceCalculator, nil, false, true},
+		{"publish failure", SourceSubscriber, errors.New("unavailable"), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{}
+			d := NewDeadLettersWithStore(store, nil, tt.source, newTestLogger())
+			d.Record(context.Background(), "topic", []byte("payload"), errors.New("failed"))
+			letter := store.letters[0]
+
+			publisher := &fakePublisher{failing: tt.failing}
+			r := Replayer{
+				publishers: map[string]interfaces.Publisher{SourceSubscriber: publisher},
+				store:      store,
+			}
+			err := r.Replay(context.Background(), letter)
+			if (err != nil) != tt.fails {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if tt.replayed {
+				if len(publisher.raw) != 1 || string(publisher.raw[0]) != "payload" {
+					t.Errorf("expected the payload to be published as received, received %q", publisher.raw)
+				}
+			} else if len(publisher.raw) != 0 {
+				t.Errorf("expected nothing to be published, received %q", publisher.raw)
+			}
+			// The dead letter is kept either way, and only marked once it has been published
+			if len(store.letters) != 1 || (store.letters[0].Replayed != nil) != tt.replayed {
+				t.Errorf("expected replayed %v, received %+v", tt.replayed, store.letters)
+			}
+		})
+	}
+}
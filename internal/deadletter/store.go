@@ -0,0 +1,137 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deadletter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arangodb/go-driver"
+	"github.com/arangodb/go-driver/http"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// Store keeps dead letters in the "deadletters" collection of an Arango database.
+type Store struct {
+	cfg      config.ArangoConfig
+	instance driver.Client
+}
+
+func NewStore(dbConfig config.DatabaseInfo) (*Store, error) {
+	cfg, ok := dbConfig.Config.(config.ArangoConfig)
+	if !ok {
+		return nil, fmt.Errorf("invalid config type, expected %s", config.DBArango)
+	}
+	conn, err := http.NewConnection(
+		http.ConnectionConfig{
+			Endpoints: []string{cfg.Provider.Uri()},
+		})
+	if err != nil {
+		return nil, err
+	}
+	client, err := driver.NewClient(
+		driver.ClientConfig{
+			Connection: conn,
+		})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{cfg: cfg, instance: client}, nil
+}
+
+// Init creates the collection if it does not exist yet.
+func (s *Store) Init(ctx context.Context) error {
+	db, err := s.instance.Database(ctx, s.cfg.DatabaseName)
+	if err != nil {
+		return err
+	}
+	exists, err := db.CollectionExists(ctx, documents.CollectionDeadLetters)
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.CreateCollection(ctx, documents.CollectionDeadLetters, nil)
+	return err
+}
+
+func (s *Store) Add(ctx context.Context, letter documents.DeadLetter) error {
+	col, err := s.collection(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = col.CreateDocument(ctx, letter)
+	return err
+}
+
+// Get returns the dead letter with the given key.
+func (s *Store) Get(ctx context.Context, key string) (documents.DeadLetter, error) {
+	col, err := s.collection(ctx)
+	if err != nil {
+		return documents.DeadLetter{}, err
+	}
+	var letter documents.DeadLetter
+	_, err = col.ReadDocument(ctx, key, &letter)
+	return letter, err
+}
+
+// List returns the dead letters, oldest first. If pending is set, the ones that have been replayed are left out.
+func (s *Store) List(ctx context.Context, pending bool) ([]documents.DeadLetter, error) {
+	db, err := s.instance.Database(ctx, s.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+	query := "FOR d IN @@letters FILTER !@pending || d.replayed == null SORT d.timestamp RETURN d"
+	bindVars := map[string]interface{}{
+		"@letters": documents.CollectionDeadLetters,
+		"pending":  pending,
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var letters []documents.DeadLetter
+	for {
+		var letter documents.DeadLetter
+		_, err := cursor.ReadDocument(ctx, &letter)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+// MarkReplayed records when the dead letter was replayed.
+func (s *Store) MarkReplayed(ctx context.Context, key string, replayed time.Time) error {
+	col, err := s.collection(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = col.UpdateDocument(ctx, key, map[string]interface{}{"replayed": replayed})
+	return err
+}
+
+func (s *Store) collection(ctx context.Context) (driver.Collection, error) {
+	db, err := s.instance.Database(ctx, s.cfg.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+	return db.Collection(ctx, documents.CollectionDeadLetters)
+}
@@ -29,6 +29,7 @@ type MongoRecord struct {
 	Timestamp    string    `json:"timestamp,omitempty"`
 	TimestampISO time.Time `json:"timestampiso,omitempty"`
 	Confidence   float64   `json:"confidence"`
+	Band         string    `json:"band,omitempty"`
 }
 
 // CopyForUpdate is necessary when updating a document in Mongo because the ObjectId on the incoming document must be
@@ -42,6 +43,7 @@ func (mr MongoRecord) CopyForUpdate() MongoRecord {
 		Timestamp:    mr.Timestamp,
 		TimestampISO: mr.TimestampISO,
 		Confidence:   mr.Confidence,
+		Band:         mr.Band,
 	}
 }
 
@@ -60,6 +62,7 @@ func ViewModelFromMongoRecord(mr MongoRecord) responses.DataViewModel {
 	parsed, _ := ulid.Parse(mr.Id)
 	vm := responses.DataViewModel{}
 	vm.Confidence = mr.Confidence
+	vm.Band = mr.Band
 	vm.Description = mr.Description
 	vm.Id = parsed
 	vm.Seed = mr.Seed
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 
 	SdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
 	"github.com/project-alvarium/scoring-apps-go/internal/config"
 )
 
@@ -28,6 +29,18 @@ type ApplicationConfig struct {
 	Endpoint  SdkConfig.ServiceInfo `json:"endpoint,omitempty"`
 	Hash      SdkConfig.HashInfo    `json:"hash,omitempty"`
 	Logging   SdkConfig.LoggingInfo `json:"logging,omitempty"`
+	Simulate  SimulateConfig        `json:"simulate,omitempty"`
+}
+
+// SimulateConfig holds the scoring settings used by the simulate endpoint. They should match the calculator's so that
+// a simulated score differs from the stored one only because of the policy. Policy is only needed to look up weights
+// by classifier name.
+type SimulateConfig struct {
+	Policy  *config.PolicyInfo                         `json:"policy,omitempty"`
+	Scoring config.ScoringInfo                         `json:"scoring,omitempty"`
+	Decay   config.DecayInfo                           `json:"decay,omitempty"`
+	Missing map[contracts.LayerType]config.MissingInfo `json:"missing,omitempty"`
+	Stack   []config.LayerDependency                   `json:"stack,omitempty"`
 }
 
 func (a ApplicationConfig) AsString() string {
@@ -0,0 +1,66 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package populator_api
+
+import (
+	"reflect"
+
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+	"github.com/project-alvarium/scoring-apps-go/pkg/responses"
+)
+
+// diffPolicies compares the weights of two versions of a policy.
+func diffPolicies(from documents.Policy, to documents.Policy) responses.PolicyDiffResponse {
+	diff := responses.PolicyDiffResponse{
+		Name: to.Name,
+		From: from.Key,
+		To:   to.Key,
+	}
+
+	previous := make(map[string]policies.Weight, len(from.Weights))
+	for _, w := range from.Weights {
+		previous[w.AnnotationKey] = w
+	}
+	for _, w := range to.Weights {
+		p, ok := previous[w.AnnotationKey]
+		delete(previous, w.AnnotationKey)
+		if !ok {
+			diff.Added = append(diff.Added, w)
+		} else if !reflect.DeepEqual(p, w) {
+			diff.Changed = append(diff.Changed, responses.WeightChange{
+				AnnotationKey: w.AnnotationKey,
+				From:          p,
+				To:            w,
+			})
+		}
+	}
+	// Iterate the earlier version again rather than the map so removals keep the sorted order
+	for _, w := range from.Weights {
+		if _, ok := previous[w.AnnotationKey]; ok {
+			diff.Removed = append(diff.Removed, w)
+		}
+	}
+	return diff
+}
+
+// findVersion returns the position of the version with the key in a policy's history.
+func findVersion(history []documents.Policy, key string) int {
+	for i, p := range history {
+		if p.Key == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,91 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package populator_api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+	"github.com/project-alvarium/scoring-apps-go/pkg/responses"
+)
+
+func TestDiffPolicies(t *testing.T) {
+	tpm := policies.Weight{AnnotationKey: "tpm", Value: 2}
+	tls := policies.Weight{AnnotationKey: "tls", Value: 1}
+	src := policies.Weight{AnnotationKey: "src", Value: 1}
+	pki := policies.Weight{AnnotationKey: "pki", Value: 1}
+	requiredTpm := policies.Weight{AnnotationKey: "tpm", Value: 2, Required: true}
+
+	tests := []struct {
+		name     string
+		from     []policies.Weight
+		to       []policies.Weight
+		expected responses.PolicyDiffResponse
+	}{
+		{"same", []policies.Weight{tls, tpm}, []policies.Weight{tls, tpm}, responses.PolicyDiffResponse{}},
+		{"added", []policies.Weight{tls}, []policies.Weight{tls, tpm}, responses.PolicyDiffResponse{
+			Added: []policies.Weight{tpm},
+		}},
+		{"removed", []policies.Weight{pki, src, tls}, []policies.Weight{tls}, responses.PolicyDiffResponse{
+			Removed: []policies.Weight{pki, src},
+		}},
+		{"changed", []policies.Weight{tls, tpm}, []policies.Weight{tls, requiredTpm}, responses.PolicyDiffResponse{
+			Changed: []responses.WeightChange{{AnnotationKey: "tpm", From: tpm, To: requiredTpm}},
+		}},
+		{"all", []policies.Weight{pki, tpm}, []policies.Weight{src, requiredTpm}, responses.PolicyDiffResponse{
+			Added:   []policies.Weight{src},
+			Removed: []policies.Weight{pki},
+			Changed: []responses.WeightChange{{AnnotationKey: "tpm", From: tpm, To: requiredTpm}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := documents.Policy{Key: "A", Name: "default", Weights: tt.from}
+			to := documents.Policy{Key: "B", Name: "default", Weights: tt.to}
+			tt.expected.Name = "default"
+			tt.expected.From = "A"
+			tt.expected.To = "B"
+
+			diff := diffPolicies(from, to)
+			if !reflect.DeepEqual(diff, tt.expected) {
+				t.Errorf("expected %+v, received %+v", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestFindVersion(t *testing.T) {
+	// A policy edited from A to B and reverted to A is listed with A first, as it was activated most recently
+	history := []documents.Policy{{Key: "A"}, {Key: "B"}}
+
+	tests := []struct {
+		name     string
+		key      string
+		expected int
+	}{
+		{"latest", "A", 0},
+		{"earlier", "B", 1},
+		{"unknown", "C", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if i := findVersion(history, tt.key); i != tt.expected {
+				t.Errorf("expected position %d, received %d", tt.expected, i)
+			}
+		})
+	}
+}
@@ -29,19 +29,27 @@ import (
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
 	"github.com/project-alvarium/scoring-apps-go/internal/db"
-	"github.com/project-alvarium/scoring-apps-go/internal/hashprovider"
 	"github.com/project-alvarium/scoring-apps-go/internal/models"
+	"github.com/project-alvarium/scoring-apps-go/pkg/hashprovider"
+	"github.com/project-alvarium/scoring-apps-go/pkg/requests"
 	"github.com/project-alvarium/scoring-apps-go/pkg/responses"
 )
 
 const (
 	headerCORS           string = "Access-Control-Allow-Origin"
 	headerCORSValue      string = "*"
+	headerCORSHeaders    string = "Access-Control-Allow-Headers"
 	headerKeyContentType string = "Content-Type"
 	headerValueJson      string = "application/json"
 )
 
-func LoadRestRoutes(r *mux.Router, dbArango *db.ArangoClient, dbMongo *db.MongoProvider, logger interfaces.Logger) {
+func LoadRestRoutes(
+	r *mux.Router,
+	dbArango *db.ArangoClient,
+	dbMongo *db.MongoProvider,
+	simulator *Simulator,
+	logger interfaces.Logger,
+) {
 	r.HandleFunc("/",
 		func(w http.ResponseWriter, r *http.Request) {
 			getIndexHandler(w, r, logger)
@@ -67,6 +75,31 @@ func LoadRestRoutes(r *mux.Router, dbArango *db.ArangoClient, dbMongo *db.MongoP
 			getDataConfidence(w, r, dbMongo, dbArango, logger)
 		}).Methods(http.MethodGet, http.MethodOptions)
 
+	r.HandleFunc("/data/{id}/confidence/history",
+		func(w http.ResponseWriter, r *http.Request) {
+			getDataConfidenceHistory(w, r, dbMongo, dbArango, logger)
+		}).Methods(http.MethodGet, http.MethodOptions)
+
+	r.HandleFunc("/data/{id}/confidence/explain",
+		func(w http.ResponseWriter, r *http.Request) {
+			getDataConfidenceExplanation(w, r, dbMongo, dbArango, logger)
+		}).Methods(http.MethodGet, http.MethodOptions)
+
+	r.HandleFunc("/simulate",
+		func(w http.ResponseWriter, r *http.Request) {
+			postSimulateHandler(w, r, dbMongo, dbArango, simulator, logger)
+		}).Methods(http.MethodPost, http.MethodOptions)
+
+	r.HandleFunc("/policies/{name}/history",
+		func(w http.ResponseWriter, r *http.Request) {
+			getPolicyHistory(w, r, dbArango, logger)
+		}).Methods(http.MethodGet, http.MethodOptions)
+
+	r.HandleFunc("/policies/{name}/diff",
+		func(w http.ResponseWriter, r *http.Request) {
+			getPolicyDiff(w, r, dbArango, logger)
+		}).Methods(http.MethodGet, http.MethodOptions)
+
 	r.HandleFunc("/hosts",
 		func(w http.ResponseWriter, r *http.Request) {
 			getHosts(w, r, dbArango, logger)
@@ -121,7 +154,7 @@ func getSampleDataHandler(
 		return
 	}
 
-	results, err := dbMongo.QueryMostRecent(r.Context(), limit)
+	results, err := dbMongo.QueryMostRecent(r.Context(), limit, r.URL.Query().Get("band"))
 	if err != nil {
 		logger.Error(err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
@@ -267,8 +300,51 @@ func getDataConfidence(
 	b, _ := json.Marshal(data)
 	key := hashprovider.DeriveHash(b)
 
-	scores, err := dbArango.QueryScoreByLayer(r.Context(), key, layer)
+	scores, err := dbArango.QueryScoreByLayer(r.Context(), key, layer, r.URL.Query().Get("policy"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	s, err := json.Marshal(scores)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Add(headerKeyContentType, headerValueJson)
+	w.Header().Add(headerCORS, headerCORSValue)
+	w.WriteHeader(http.StatusOK)
+	w.Write(s)
+}
+
+func getDataConfidenceHistory(
+	w http.ResponseWriter,
+	r *http.Request,
+	dbMongo *db.MongoProvider,
+	dbArango *db.ArangoClient,
+	logger interfaces.Logger,
+) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	record, err := dbMongo.FetchById(r.Context(), id)
+	if err != nil {
+		logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	data := models.SampleFromMongoRecord(record)
+	b, _ := json.Marshal(data)
+	key := hashprovider.DeriveHash(b)
+
+	scores, err := dbArango.QueryScoreHistory(r.Context(), key, r.URL.Query().Get("policy"))
 	if err != nil {
+		logger.Error(err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
@@ -285,6 +361,245 @@ func getDataConfidence(
 	w.Write(s)
 }
 
+func getDataConfidenceExplanation(
+	w http.ResponseWriter,
+	r *http.Request,
+	dbMongo *db.MongoProvider,
+	dbArango *db.ArangoClient,
+	logger interfaces.Logger,
+) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	record, err := dbMongo.FetchById(r.Context(), id)
+	if err != nil {
+		logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	data := models.SampleFromMongoRecord(record)
+	b, _ := json.Marshal(data)
+	key := hashprovider.DeriveHash(b)
+
+	score, err := dbArango.QueryScore(r.Context(), key, r.URL.Query().Get("policy"))
+	if err != nil {
+		logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if score.DataRef == "" {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no score found for " + id))
+		return
+	}
+
+	response := responses.ScoreExplanationResponse{
+		DataRef:     score.DataRef,
+		ScoreKey:    score.Key.String(),
+		Version:     score.Version,
+		Confidence:  score.Confidence,
+		Explanation: score.Explanation,
+	}
+	s, err := json.Marshal(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Add(headerKeyContentType, headerValueJson)
+	w.Header().Add(headerCORS, headerCORSValue)
+	w.WriteHeader(http.StatusOK)
+	w.Write(s)
+}
+
+func postSimulateHandler(
+	w http.ResponseWriter,
+	r *http.Request,
+	dbMongo *db.MongoProvider,
+	dbArango *db.ArangoClient,
+	simulator *Simulator,
+	logger interfaces.Logger,
+) {
+	defer r.Body.Close()
+
+	// Answer the CORS preflight sent by browsers ahead of a JSON POST
+	if r.Method == http.MethodOptions {
+		w.Header().Add(headerCORS, headerCORSValue)
+		w.Header().Add(headerCORSHeaders, headerKeyContentType)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req requests.SimulateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		logger.Write(slog.LevelDebug, "Bad request: "+err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if req.Id == "" && req.Key == "" {
+		errMsg := "Bad request: either an id or a key is required"
+		logger.Write(slog.LevelDebug, errMsg)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(errMsg))
+		return
+	}
+
+	p, err := simulator.Policy(req.Classifier, req.Policy)
+	if err != nil {
+		logger.Write(slog.LevelDebug, "Bad request: "+err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	key := req.Key
+	if key == "" {
+		record, err := dbMongo.FetchById(r.Context(), req.Id)
+		if err != nil {
+			logger.Error(err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		data := models.SampleFromMongoRecord(record)
+		b, _ := json.Marshal(data)
+		key = hashprovider.DeriveHash(b)
+	}
+
+	annotations, err := dbArango.QueryDataAnnotations(r.Context(), key)
+	if err != nil {
+		logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if len(annotations) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no annotations found for " + key))
+		return
+	}
+
+	response, err := simulator.Simulate(r.Context(), key, annotations, p, req.BasePolicy)
+	if err != nil {
+		logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	current, err := dbArango.QueryScore(r.Context(), key, req.BasePolicy)
+	if err != nil {
+		logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if current.DataRef != "" {
+		response.CurrentConfidence = &current.Confidence
+	}
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Add(headerKeyContentType, headerValueJson)
+	w.Header().Add(headerCORS, headerCORSValue)
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+func getPolicyHistory(
+	w http.ResponseWriter,
+	r *http.Request,
+	dbArango *db.ArangoClient,
+	logger interfaces.Logger,
+) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	history, err := dbArango.QueryPolicyHistory(r.Context(), name)
+	if err != nil {
+		logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if len(history) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no versions found for policy " + name))
+		return
+	}
+
+	b, err := json.Marshal(history)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Add(headerKeyContentType, headerValueJson)
+	w.Header().Add(headerCORS, headerCORSValue)
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// getPolicyDiff compares the versions of a policy given by the "from" and "to" keys. Without "to" the latest version is
+// used, and without "from" the version before "to".
+func getPolicyDiff(
+	w http.ResponseWriter,
+	r *http.Request,
+	dbArango *db.ArangoClient,
+	logger interfaces.Logger,
+) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	history, err := dbArango.QueryPolicyHistory(r.Context(), name)
+	if err != nil {
+		logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	to := 0
+	if key := r.URL.Query().Get("to"); key != "" {
+		to = findVersion(history, key)
+	}
+	from := to + 1
+	if key := r.URL.Query().Get("from"); key != "" {
+		from = findVersion(history, key)
+	}
+	if to < 0 || from < 0 || to >= len(history) || from >= len(history) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("versions to compare not found for policy " + name))
+		return
+	}
+
+	b, err := json.Marshal(diffPolicies(history[from], history[to]))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Add(headerKeyContentType, headerValueJson)
+	w.Header().Add(headerCORS, headerCORSValue)
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
 func getHosts(
 	w http.ResponseWriter,
 	r *http.Request,
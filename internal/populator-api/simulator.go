@@ -0,0 +1,121 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package populator_api
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/decay"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/policy"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/scoring"
+	"github.com/project-alvarium/scoring-apps-go/internal/calculator/types"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+	"github.com/project-alvarium/scoring-apps-go/pkg/responses"
+)
+
+// omittedSteps are the steps of the calculator that a simulation does not apply. Lineage propagation needs the scores
+// of the parent data and OPA decisions need the decision rule, neither of which the simulator has.
+var omittedSteps = []string{"propagation", "decision"}
+
+// Simulator scores a data item the way the calculator would, against the stored annotations and lower layer scores,
+// without writing anything back.
+type Simulator struct {
+	decay    decay.DecayFunction
+	finder   scoring.ScoreFinder
+	layers   *types.LayerGraph
+	missing  map[contracts.LayerType]config.MissingInfo
+	provider policy.PolicyProvider // provider is nil if no policy is configured
+	strategy scoring.ScoringStrategy
+}
+
+func NewSimulator(cfg SimulateConfig, finder scoring.ScoreFinder, logger interfaces.Logger) (*Simulator, error) {
+	strategy, err := scoring.NewScoringStrategy(cfg.Scoring)
+	if err != nil {
+		return nil, err
+	}
+	decayFn, err := decay.NewDecayFunction(cfg.Decay)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := types.NewLayerGraph(cfg.Stack)
+	if err != nil {
+		return nil, err
+	}
+
+	s := Simulator{
+		decay:    decayFn,
+		finder:   finder,
+		layers:   layers,
+		missing:  cfg.Missing,
+		strategy: strategy,
+	}
+	if cfg.Policy != nil {
+		s.provider, err = policy.NewPolicyProvider(*cfg.Policy, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// Policy returns the inline policy if one is supplied, otherwise the policy of the classifier.
+func (s *Simulator) Policy(classifier string, inline *policies.DcfPolicy) (policies.DcfPolicy, error) {
+	if inline != nil {
+		return *inline, nil
+	}
+	if classifier == "" {
+		return policies.DcfPolicy{}, errors.New("either a policy or a classifier is required")
+	}
+	if s.provider == nil {
+		return policies.DcfPolicy{}, errors.New("no policy provider is configured to look up classifier " + classifier)
+	}
+	return s.provider.GetPolicy(classifier)
+}
+
+// Simulate calculates the score of the annotations under the policy, building on the lower layer scores stored for the
+// base policy, or under any policy if no base is given. The lower layer scores are found exactly as the calculator finds
+// them, except that a deferred score is simply left out since there is nothing to wait for. Lineage propagation and OPA
+// decisions are not applied, and the response lists them as omitted.
+func (s *Simulator) Simulate(
+	ctx context.Context,
+	key string,
+	annotations []documents.Annotation,
+	p policies.DcfPolicy,
+	base string,
+) (responses.SimulationResponse, error) {
+	dependencies, missing, err := scoring.LowerLayers(ctx, s.finder, s.layers, s.missing, annotations, base)
+	if err != nil {
+		return responses.SimulationResponse{}, err
+	}
+	score := scoring.NewScore(s.strategy, s.decay, key, annotations, p, dependencies)
+	return responses.SimulationResponse{
+		DataRef:     key,
+		Layer:       score.Layer,
+		Policy:      score.Policy,
+		Passed:      score.Passed,
+		Count:       score.Count,
+		Confidence:  score.Confidence,
+		Band:        score.Band,
+		Explanation: score.Explanation,
+		Missing:     missing,
+		Omitted:     slices.Clone(omittedSteps),
+	}, nil
+}
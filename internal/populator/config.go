@@ -27,6 +27,9 @@ type ApplicationConfig struct {
 	Databases []config.DatabaseInfo `json:"databases,omitempty"`
 	Hash      SdkConfig.HashInfo    `json:"hash,omitempty"`
 	Logging   SdkConfig.LoggingInfo `json:"logging,omitempty"`
+	// Policy selects the policy whose score is written to the data. If empty, the most recent score under any policy
+	// is used.
+	Policy string `json:"policy,omitempty"`
 }
 
 func (a ApplicationConfig) AsString() string {
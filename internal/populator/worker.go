@@ -24,21 +24,24 @@ import (
 
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
 	"github.com/project-alvarium/scoring-apps-go/internal/db"
-	"github.com/project-alvarium/scoring-apps-go/internal/hashprovider"
 	"github.com/project-alvarium/scoring-apps-go/internal/models"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/hashprovider"
 )
 
 type Worker struct {
 	dbArango *db.ArangoClient
 	dbMongo  *db.MongoProvider
 	logger   interfaces.Logger
+	policy   string
 }
 
-func NewWorker(dbArango *db.ArangoClient, dbMongo *db.MongoProvider, logger interfaces.Logger) Worker {
+func NewWorker(dbArango *db.ArangoClient, dbMongo *db.MongoProvider, policy string, logger interfaces.Logger) Worker {
 	return Worker{
 		dbArango: dbArango,
 		dbMongo:  dbMongo,
 		logger:   logger,
+		policy:   policy,
 	}
 }
 
@@ -62,14 +65,13 @@ func (w *Worker) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bool
 					// SHA256 is being handled.
 					b, _ := json.Marshal(&appData)
 					key := hashprovider.DeriveHash(b)
-					score, err := w.dbArango.QueryScore(ctx, key)
+					score, err := w.dbArango.QueryScore(ctx, key, w.policy)
 					if err != nil {
 						w.logger.Error(err.Error())
 						continue
 					}
 					w.logger.Write(slog.LevelDebug, fmt.Sprintf("score for key %s is %v", key, score.Confidence))
-					if score.Confidence > 0 {
-						item.Confidence = score.Confidence
+					if item, ok := populate(item, score); ok {
 						err = w.dbMongo.UpdateDocument(ctx, item)
 						if err != nil {
 							w.logger.Error(err.Error())
@@ -96,3 +98,15 @@ func (w *Worker) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup) bool
 	}()
 	return true
 }
+
+// populate copies the confidence and band of the score onto the record. A record scored at zero confidence still gets
+// its band, such as untrusted, and keeps being polled in case it is scored higher later. It returns false if the record
+// already holds the score, so that it is not written again on every poll.
+func populate(item models.MongoRecord, score documents.Score) (models.MongoRecord, bool) {
+	if item.Confidence == score.Confidence && item.Band == score.Band {
+		return item, false
+	}
+	item.Confidence = score.Confidence
+	item.Band = score.Band
+	return item, true
+}
@@ -0,0 +1,52 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package populator
+
+import (
+	"testing"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/models"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+func TestPopulate(t *testing.T) {
+	tests := []struct {
+		name               string
+		item               models.MongoRecord
+		score              documents.Score
+		expectedUpdate     bool
+		expectedBand       string
+		expectedConfidence float64
+	}{
+		{"trusted", models.MongoRecord{}, documents.Score{Confidence: 0.9, Band: "trusted"}, true, "trusted", 0.9},
+		{"zero confidence", models.MongoRecord{}, documents.Score{Confidence: 0, Band: "untrusted"}, true, "untrusted", 0},
+		{"already populated", models.MongoRecord{Band: "untrusted"}, documents.Score{Confidence: 0, Band: "untrusted"},
+			false, "untrusted", 0},
+		{"scored higher later", models.MongoRecord{Band: "untrusted"}, documents.Score{Confidence: 0.6, Band: "suspect"},
+			true, "suspect", 0.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, ok := populate(tt.item, tt.score)
+			if ok != tt.expectedUpdate {
+				t.Errorf("expected update to be %v, received %v", tt.expectedUpdate, ok)
+			}
+			if item.Band != tt.expectedBand || item.Confidence != tt.expectedConfidence {
+				t.Errorf("expected band %s at %v, received %s at %v", tt.expectedBand, tt.expectedConfidence, item.Band,
+					item.Confidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package interfaces
+
+import "fmt"
+
+// MessageError is sent on the error channel of a Subscriber when a message is received that cannot be decoded. The
+// raw payload is kept so that the message can be dead-lettered.
+type MessageError struct {
+	Topic   string
+	Payload []byte
+	Err     error
+}
+
+func (e *MessageError) Error() string {
+	return fmt.Sprintf("malformed message received on %s: %s", e.Topic, e.Err.Error())
+}
+
+func (e *MessageError) Unwrap() error {
+	return e.Err
+}
@@ -21,5 +21,7 @@ import (
 
 type Publisher interface {
 	Publish(ctx context.Context, message msg.PublishWrapper) error
+	// PublishRaw sends the payload as is, for example to replay a message exactly as it was first received
+	PublishRaw(ctx context.Context, payload []byte) error
 	Close() error
 }
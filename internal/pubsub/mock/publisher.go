@@ -38,6 +38,10 @@ func (p *mockPublisher) Publish(ctx context.Context, message msg.PublishWrapper)
 	return nil
 }
 
+func (p *mockPublisher) PublishRaw(ctx context.Context, payload []byte) error {
+	return nil
+}
+
 func (p *mockPublisher) Close() error {
 	return nil
 }
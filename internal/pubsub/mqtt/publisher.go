@@ -51,13 +51,17 @@ func NewMqttPublisher(cfg config.MqttConfig) interfaces.Publisher {
 }
 
 func (p *mqttPublisher) Publish(ctx context.Context, message msg.PublishWrapper) error {
+	b, _ := json.Marshal(message)
+	return p.PublishRaw(ctx, b)
+}
+
+func (p *mqttPublisher) PublishRaw(ctx context.Context, b []byte) error {
 	// Verify connectivity first. If it's been dropped, this will attempt one reconnect before publish
 	err := p.reconnect()
 	if err != nil {
 		return err
 	}
 
-	b, _ := json.Marshal(message)
 	// publish to all topics
 	for _, topic := range p.endpoint.Topics {
 		token := p.mqttClient.Publish(topic, byte(p.endpoint.Qos), false, b)
@@ -27,6 +27,7 @@ import (
 
 type mqttSubscriber struct {
 	chPub      chan<- msg.SubscribeWrapper
+	chErrors   chan<- error
 	endpoint   config.MqttConfig
 	mqttClient MQTT.Client
 }
@@ -56,6 +57,7 @@ func (s *mqttSubscriber) Subscribe(ctx context.Context, chMessage chan<- msg.Sub
 	}
 
 	s.chPub = chMessage
+	s.chErrors = chErrors
 	if len(s.endpoint.Topics) > 1 {
 		topicsMap := make(map[string]byte)
 		// build topic qos map
@@ -111,6 +113,11 @@ func (s *mqttSubscriber) reconnect() error {
 // General message handing func
 func (s *mqttSubscriber) mqttMessageHandler(client MQTT.Client, mqttMsg MQTT.Message) {
 	var wrap msg.SubscribeWrapper
-	json.Unmarshal(mqttMsg.Payload(), &wrap)
+	err := json.Unmarshal(mqttMsg.Payload(), &wrap)
+	if err != nil {
+		// Report the payload rather than forwarding an empty message
+		s.chErrors <- &interfaces.MessageError{Topic: mqttMsg.Topic(), Payload: mqttMsg.Payload(), Err: err}
+		return
+	}
 	s.chPub <- wrap
 }
@@ -0,0 +1,87 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package mqtt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/project-alvarium/scoring-apps-go/internal/pubsub/interfaces"
+	"github.com/project-alvarium/scoring-apps-go/pkg/msg"
+)
+
+func TestMqttMessageHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		forwarded bool
+	}{
+		{"valid", `{"messageType":"ScoreCalculated","content":"a2V5"}`, true},
+		{"malformed", `{"messageType":`, false},
+		{"not an object", `"key"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chMessages := make(chan msg.SubscribeWrapper, 1)
+			chErrors := make(chan error, 1)
+			s := mqttSubscriber{chPub: chMessages, chErrors: chErrors}
+			s.mqttMessageHandler(nil, fakeMessage{topic: "topic", payload: []byte(tt.payload)})
+
+			select {
+			case wrap := <-chMessages:
+				if !tt.forwarded {
+					t.Fatalf("expected no message to be forwarded, received %+v", wrap)
+				}
+				if wrap.MessageType != "ScoreCalculated" || string(wrap.Content) != "key" {
+					t.Errorf("unexpected message %+v", wrap)
+				}
+			default:
+				if tt.forwarded {
+					t.Fatal("expected the message to be forwarded")
+				}
+			}
+
+			// A payload that cannot be decoded is reported with its topic and payload so that it can be dead-lettered
+			select {
+			case err := <-chErrors:
+				var malformed *interfaces.MessageError
+				if tt.forwarded || !errors.As(err, &malformed) {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if malformed.Topic != "topic" || string(malformed.Payload) != tt.payload {
+					t.Errorf("unexpected message error %+v", malformed)
+				}
+			default:
+				if !tt.forwarded {
+					t.Fatal("expected a message error")
+				}
+			}
+		})
+	}
+}
+
+// fakeMessage is a received MQTT message.
+type fakeMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m fakeMessage) Duplicate() bool   { return false }
+func (m fakeMessage) Qos() byte         { return 0 }
+func (m fakeMessage) Retained() bool    { return false }
+func (m fakeMessage) Topic() string     { return m.topic }
+func (m fakeMessage) MessageID() uint16 { return 0 }
+func (m fakeMessage) Payload() []byte   { return m.payload }
+func (m fakeMessage) Ack()              {}
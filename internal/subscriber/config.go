@@ -27,6 +27,11 @@ type ApplicationConfig struct {
 	Stream   config.PubSubInfo     `json:"stream,omitempty"`
 	Logging  sdkConfig.LoggingInfo `json:"logging,omitempty"`
 	Key      string                `json:"preSharedKey,omitempty"` // Key is for IOTA support, shared key. Needs to be moved into SDK IotaStreamConfig
+	// Verification lists the public keys annotation signatures are verified against. Signatures are not verified if
+	// no keys are given.
+	Verification config.VerificationInfo `json:"verification,omitempty"`
+	DeadLetter   config.DeadLetterInfo   `json:"deadLetter,omitempty"`
+	Unrecognized config.UnrecognizedInfo `json:"unrecognized,omitempty"` // Unrecognized handles messages with an unknown SDK action
 }
 
 func (a ApplicationConfig) AsString() string {
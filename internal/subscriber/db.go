@@ -17,6 +17,7 @@ package subscriber
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -28,28 +29,50 @@ import (
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/message"
 	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/deadletter"
 	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/hashprovider"
 )
 
 type arangoClient struct {
-	cfg    config.ArangoConfig
-	chPub  chan string
-	chSub  chan message.SubscribeWrapper
-	client driver.Client
-	logger interfaces.Logger
+	cfg         config.ArangoConfig
+	chPub       chan string
+	chSub       chan message.SubscribeWrapper
+	client      driver.Client
+	deadLetters *deadletter.DeadLetters // deadLetters keeps the messages that fail to be persisted, if enabled
+	handlers    map[message.SdkAction]handler
+	logger      interfaces.Logger
+	// unrecognized decides what happens to messages with an action that is not recognized
+	unrecognized config.UnrecognizedAction
+	verifier     *Verifier // verifier checks annotation signatures, if any public keys are configured
 }
 
-func NewArangoClient(sub chan message.SubscribeWrapper, pub chan string, dbConfig config.DatabaseInfo, logger interfaces.Logger) (arangoClient, error) {
+func NewArangoClient(
+	sub chan message.SubscribeWrapper,
+	pub chan string,
+	dbConfig config.DatabaseInfo,
+	verifier *Verifier,
+	deadLetters *deadletter.DeadLetters,
+	unrecognized config.UnrecognizedInfo,
+	logger interfaces.Logger,
+) (arangoClient, error) {
 	cfg, ok := dbConfig.Config.(config.ArangoConfig)
 	if !ok {
 		return arangoClient{}, fmt.Errorf("invalid config type, expected %s", config.DBArango)
 	}
-	c := arangoClient{
-		cfg:    cfg,
-		chPub:  pub,
-		chSub:  sub,
-		logger: logger,
+	if unrecognized.Action == config.DeadLetterUnrecognized && deadLetters == nil {
+		return arangoClient{}, errors.New("dead letters must be enabled to keep messages with unrecognized actions")
 	}
+	c := arangoClient{
+		cfg:          cfg,
+		chPub:        pub,
+		chSub:        sub,
+		deadLetters:  deadLetters,
+		logger:       logger,
+		unrecognized: unrecognized.Action,
+		verifier:     verifier,
+	}
+	c.handlers = c.newHandlers()
 
 	conn, err := http.NewConnection(
 		http.ConnectionConfig{
@@ -81,28 +104,10 @@ func (c *arangoClient) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup)
 
 		for {
 			item, ok := <-c.chSub
-			if ok {
-				switch item.Action {
-				case message.ActionCreate:
-					c.logger.Write(slog.LevelDebug, "handling create")
-					err = c.handleCreateTransit(ctx, item.Content)
-				case message.ActionTransit:
-					c.logger.Write(slog.LevelDebug, "handling transit")
-					err = c.handleCreateTransit(ctx, item.Content)
-				case message.ActionMutate:
-					c.logger.Write(slog.LevelDebug, "handling mutate")
-					err = c.handleMutate(ctx, item.Content)
-				default:
-					c.logger.Write(slog.LevelDebug, "unrecognized item.Action value %s", item.Action)
-					continue
-				}
-
-				if err != nil {
-					c.logger.Error(err.Error())
-				}
-			} else {
+			if !ok {
 				return
 			}
+			c.handle(ctx, item)
 		}
 	}()
 
@@ -117,7 +122,41 @@ func (c *arangoClient) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup)
 	return true
 }
 
-func (c *arangoClient) handleMutate(ctx context.Context, content []byte) error {
+// handler persists the content of a message received with an SDK action.
+type handler func(ctx context.Context, action message.SdkAction, content []byte) error
+
+// newHandlers maps every SDK action the subscriber recognizes to the handler of its messages.
+func (c *arangoClient) newHandlers() map[message.SdkAction]handler {
+	return map[message.SdkAction]handler{
+		message.ActionCreate:    c.handleAnnotations,
+		message.ActionTransit:   c.handleAnnotations,
+		message.ActionPublish:   c.handleAnnotations,
+		message.ActionMutate:    c.handleMutate,
+		message.ActionBroadcast: c.handleStream,
+		message.ActionEndStream: c.handleStream,
+	}
+}
+
+// handle passes the message to the handler of its action. A message that fails to be persisted is dead-lettered, if
+// enabled, as it was received so that it can be replayed.
+func (c *arangoClient) handle(ctx context.Context, item message.SubscribeWrapper) {
+	h, ok := c.handlers[item.Action]
+	if !ok {
+		c.handleUnrecognized(ctx, item)
+		return
+	}
+	c.logger.Write(slog.LevelDebug, fmt.Sprintf("handling %s", item.Action))
+	err := h(ctx, item.Action, item.Content)
+	if err != nil {
+		c.logger.Error(err.Error())
+		if c.deadLetters != nil {
+			b, _ := json.Marshal(item)
+			c.deadLetters.Record(ctx, "", b, err)
+		}
+	}
+}
+
+func (c *arangoClient) handleMutate(ctx context.Context, action message.SdkAction, content []byte) error {
 	var list sdkContract.AnnotationList
 	err := json.Unmarshal(content, &list)
 	if err != nil {
@@ -132,68 +171,46 @@ func (c *arangoClient) handleMutate(ctx context.Context, content []byte) error {
 	if err != nil {
 		return err
 	}
-	graph, err := db.Graph(ctx, c.cfg.GraphName)
-	if err != nil {
-		return err
-	}
-	data, err := graph.VertexCollection(ctx, documents.VertexData) // Fetch the "data" collection
-	if err != nil {
+	items, err := c.screen(ctx, db, action, list.Items)
+	if err != nil || len(items) == 0 {
 		return err
 	}
 	// Find the "Src" annotation first. That will point to the previous version of the data being mutated.
 	var dataRef string
-	for _, item := range list.Items {
-		if item.Kind == sdkContract.AnnotationSource {
-			dataRef = item.Key
+	for _, item := range items {
+		if item.Kind == string(sdkContract.AnnotationSource) {
+			dataRef = item.DataRef
 			break
 		}
 	}
 	// This should already exist, but it will be interesting from a reporting perspective if we create it here b/c the
 	// upstream vertex will have no annotations.
-	err = c.createDataDocument(ctx, dataRef, data)
-	if err != nil {
-		return err
-	}
+	w := listWrite{data: []string{dataRef}}
 
 	var itemKey string
-	lineageCreated := false
-	for _, item := range list.Items {
-		if item.Kind != sdkContract.AnnotationSource {
-			if !lineageCreated {
-				// create the target vertex for new data version
-				err = c.createDataDocument(ctx, item.Key, data)
-				if err != nil {
-					return err
-				}
-				// then link them together
-				err = c.createEdge(ctx, dataRef, item.Key, documents.EdgeLineage, graph)
-				if err != nil {
-					return err
-				}
-				lineageCreated = true
-			}
-
-			// With the DataDocument created, now create the annotations
-			annotation, err := graph.VertexCollection(ctx, c.cfg.Vertexes[0])
-			if err != nil {
-				return err
-			}
-			err = c.createAnnotationDocument(ctx, item, annotation)
-			if err != nil {
-				return err
+	for _, item := range items {
+		if item.Kind != string(sdkContract.AnnotationSource) {
+			if itemKey == "" {
+				// create the target vertex for new data version, then link them together
+				w.data = append(w.data, item.DataRef)
+				w.lineage = append(w.lineage, newLineage(item.DataRef, dataRef))
 			}
-			err = c.createEdge(ctx, item.Key, item.Id.String(), documents.EdgeTrust, graph)
-			if err != nil {
-				return err
-			}
-			itemKey = item.Key
+			w.annotations = append(w.annotations, item)
+			itemKey = item.DataRef
 		}
 	}
+
+	persisted, err := c.write(ctx, db, w)
+	if err != nil || !persisted || itemKey == "" {
+		return err
+	}
 	c.chPub <- itemKey
 	return nil
 }
 
-func (c *arangoClient) handleCreateTransit(ctx context.Context, content []byte) error {
+// handleAnnotations persists the annotations of a create, transit or publish. Annotations of a publish attest to how
+// the data was handled before being emitted, and are linked to the emitted data like any other.
+func (c *arangoClient) handleAnnotations(ctx context.Context, action message.SdkAction, content []byte) error {
 	var list sdkContract.AnnotationList
 	err := json.Unmarshal(content, &list)
 	if err != nil {
@@ -208,40 +225,237 @@ func (c *arangoClient) handleCreateTransit(ctx context.Context, content []byte)
 	if err != nil {
 		return err
 	}
-	graph, err := db.Graph(ctx, c.cfg.GraphName)
+	items, err := c.screen(ctx, db, action, list.Items)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	// All of the items will have the same key since they all related to the same piece of data.
+	w := listWrite{data: []string{items[0].DataRef}, annotations: items}
+
+	persisted, err := c.write(ctx, db, w)
+	if err != nil || !persisted {
+		return err
+	}
+	c.chPub <- items[0].DataRef
+	return nil
+}
+
+// handleStream records a stream being opened by a broadcast of its topic, or closed by an end-stream. The content of
+// either message is the topic itself.
+func (c *arangoClient) handleStream(ctx context.Context, action message.SdkAction, content []byte) error {
+	stream, mode, err := newStream(action, content, time.Now())
 	if err != nil {
 		return err
 	}
-	data, err := graph.VertexCollection(ctx, documents.VertexData) // Fetch the "data" collection
+	db, err := c.client.Database(ctx, c.cfg.DatabaseName)
 	if err != nil {
 		return err
 	}
-	// For a create, all of the items will have the same key since they all related to the same piece of data.
-	err = c.createDataDocument(ctx, list.Items[0].Key, data)
+	col, err := db.Collection(ctx, documents.VertexStreams)
 	if err != nil {
 		return err
 	}
+	_, err = col.CreateDocument(driver.WithOverwriteMode(ctx, mode), stream)
+	if err != nil {
+		return err
+	}
+	c.logger.Write(slog.LevelDebug, fmt.Sprintf("%s recorded for stream %s", action, stream.Topic))
+	return nil
+}
+
+// newStream returns the stream vertex written for a broadcast or end-stream of the topic, along with how it is written
+// over an existing vertex. A broadcast reopens the stream, while an end-stream keeps when it was opened.
+func newStream(action message.SdkAction, topic []byte, now time.Time) (documents.Stream, driver.OverwriteMode, error) {
+	if len(topic) == 0 {
+		return documents.Stream{}, "", fmt.Errorf("%s received without a topic", action)
+	}
+	stream := documents.Stream{Key: hashprovider.DeriveHash(topic), Topic: string(topic)}
+	if action == message.ActionBroadcast {
+		stream.Opened = &now
+		return stream, driver.OverwriteModeReplace, nil
+	}
+	stream.Closed = &now
+	return stream, driver.OverwriteModeUpdate, nil
+}
+
+// handleUnrecognized applies the configured handling to a message whose action is not recognized.
+func (c *arangoClient) handleUnrecognized(ctx context.Context, item message.SubscribeWrapper) {
+	msg := fmt.Sprintf("unrecognized item.Action value %s", item.Action)
+	switch c.unrecognized {
+	case config.LogUnrecognized:
+		c.logger.Write(slog.LevelWarn, msg)
+	case config.DeadLetterUnrecognized:
+		// Kept so that it can be replayed once the action is supported
+		b, _ := json.Marshal(item)
+		c.deadLetters.Record(ctx, "", b, errors.New(msg))
+	default:
+		c.logger.Write(slog.LevelDebug, msg)
+	}
+}
+
+// listWrite holds everything persisted for one AnnotationList.
+type listWrite struct {
+	data        []string // data holds the keys of the data vertices, which are only created if they do not exist
+	annotations []documents.Annotation
+	lineage     []documents.Lineage
+}
+
+// newTrust links an annotation to its data. The edge shares the key of the annotation.
+func newTrust(a documents.Annotation) documents.Trust {
+	return documents.Trust{
+		Key:  a.Key,
+		From: fmt.Sprintf("%s/%s", documents.VertexData, a.DataRef),
+		To:   fmt.Sprintf("%s/%s", documents.VertexAnnotations, a.Key),
+	}
+}
+
+// newLineage links a new version of data to the version it was mutated from. The key is derived from both.
+func newLineage(dataRef string, previous string) documents.Lineage {
+	return documents.Lineage{
+		Key:  hashprovider.DeriveHash([]byte(dataRef + "/" + previous)),
+		From: fmt.Sprintf("%s/%s", documents.VertexData, dataRef),
+		To:   fmt.Sprintf("%s/%s", documents.VertexData, previous),
+	}
+}
+
+// write persists an AnnotationList in one stream transaction, so that a failure part way through leaves nothing
+// behind. Each kind of document is written in a single batch. Annotations already persisted with the same content are
+// only counted as redelivered, while those whose content has changed are replaced along with their trust edge. It
+// returns whether any annotation was new, since a list received again does not need to be scored again.
+func (c *arangoClient) write(ctx context.Context, db driver.Database, w listWrite) (bool, error) {
+	cols := driver.TransactionCollections{
+		Write: []string{documents.VertexData, documents.VertexAnnotations, documents.EdgeTrust, documents.EdgeLineage},
+	}
+	tid, err := db.BeginTransaction(ctx, cols, nil)
+	if err != nil {
+		return false, err
+	}
+	tctx := driver.WithTransactionID(ctx, tid)
+
+	r, err := c.receive(tctx, db, w.annotations)
+	if err == nil {
+		err = c.persist(tctx, db, w, r)
+	}
+	if err != nil {
+		abortErr := db.AbortTransaction(ctx, tid, nil)
+		if abortErr != nil {
+			c.logger.Error(abortErr.Error())
+		}
+		return false, err
+	}
+	err = db.CommitTransaction(ctx, tid, nil)
+	if err != nil {
+		return false, err
+	}
+
+	dataRef := w.data[len(w.data)-1]
+	if r.changed > 0 {
+		c.logger.Write(slog.LevelWarn, fmt.Sprintf("%d annotations for %s replaced with different content", r.changed, dataRef))
+	}
+	c.logger.Write(slog.LevelDebug, fmt.Sprintf("%d annotations persisted, %d duplicates received for %s",
+		len(r.fresh), len(r.duplicates), dataRef))
+	return len(r.fresh) > 0, nil
+}
+
+// receive reads the annotations already persisted under the incoming keys and classifies the incoming annotations.
+func (c *arangoClient) receive(ctx context.Context, db driver.Database, annotations []documents.Annotation) (received, error) {
+	keys := make([]string, 0, len(annotations))
+	for _, a := range annotations {
+		keys = append(keys, a.Key)
+	}
+	query := "FOR a IN @@annotations FILTER a._key IN @keys RETURN a"
+	bindVars := map[string]interface{}{
+		"@annotations": documents.VertexAnnotations,
+		"keys":         keys,
+	}
+	cursor, err := db.Query(ctx, query, bindVars)
+	if err != nil {
+		return received{}, err
+	}
+	defer cursor.Close()
+
+	existing := make(map[string]documents.Annotation)
+	for {
+		var a documents.Annotation
+		_, err := cursor.ReadDocument(ctx, &a)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			return received{}, err
+		}
+		existing[a.Key] = a
+	}
+	return classify(annotations, existing), nil
+}
 
-	// With the DataDocument created, now create the annotations
-	annotation, err := graph.VertexCollection(ctx, documents.VertexAnnotations)
+// persist writes the classified annotations of a list inside the transaction carried by the context.
+func (c *arangoClient) persist(ctx context.Context, db driver.Database, w listWrite, r received) error {
+	var data []documents.Data
+	for _, key := range w.data {
+		data = append(data, documents.Data{Key: key, Timestamp: time.Now()})
+	}
+	// Existing data vertices and lineage edges are left as they are
+	ignore := driver.WithOverwriteMode(ctx, driver.OverwriteModeIgnore)
+	err := c.createDocuments(ignore, db, documents.VertexData, data)
 	if err != nil {
 		return err
 	}
-	for _, a := range list.Items {
-		err := c.createAnnotationDocument(ctx, a, annotation)
+	if len(w.lineage) > 0 {
+		err = c.createDocuments(ignore, db, documents.EdgeLineage, w.lineage)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Annotations whose content changed replace the persisted version, as does their trust edge
+	if len(r.fresh) > 0 {
+		replace := driver.WithOverwriteMode(ctx, driver.OverwriteModeReplace)
+		err = c.createDocuments(replace, db, documents.VertexAnnotations, r.fresh)
+		if err != nil {
+			return err
+		}
+		var trust []documents.Trust
+		for _, a := range r.fresh {
+			trust = append(trust, newTrust(a))
+		}
+		err = c.createDocuments(replace, db, documents.EdgeTrust, trust)
 		if err != nil {
 			return err
 		}
+	}
 
-		err = c.createEdge(ctx, a.Key, a.Id.String(), documents.EdgeTrust, graph)
+	if len(r.duplicates) > 0 {
+		keys := make([]string, 0, len(r.duplicates))
+		updates := make([]map[string]interface{}, 0, len(r.duplicates))
+		for _, a := range r.duplicates {
+			keys = append(keys, a.Key)
+			updates = append(updates, map[string]interface{}{"redelivered": a.Redelivered})
+		}
+		col, err := db.Collection(ctx, documents.VertexAnnotations)
+		if err != nil {
+			return err
+		}
+		_, errs, err := col.UpdateDocuments(ctx, keys, updates)
 		if err != nil {
 			return err
 		}
+		return errs.FirstNonNil()
 	}
-	c.chPub <- list.Items[0].Key
 	return nil
 }
 
+func (c *arangoClient) createDocuments(ctx context.Context, db driver.Database, name string, docs interface{}) error {
+	col, err := db.Collection(ctx, name)
+	if err != nil {
+		return err
+	}
+	_, errs, err := col.CreateDocuments(ctx, docs)
+	if err != nil {
+		return err
+	}
+	return errs.FirstNonNil()
+}
+
 func (c *arangoClient) initGraph(ctx context.Context) error {
 	exists, err := c.client.DatabaseExists(ctx, c.cfg.DatabaseName)
 	if err != nil {
@@ -294,66 +508,100 @@ func (c *arangoClient) initGraph(ctx context.Context) error {
 		}
 	} else {
 		c.logger.Write(slog.LevelDebug, "graph exists "+c.cfg.GraphName)
+		err = c.extendGraph(ctx, db)
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
-}
-
-func (c *arangoClient) createAnnotationDocument(ctx context.Context, a sdkContract.Annotation, collection driver.Collection) error {
-	c.logger.Write(slog.LevelDebug, "annotation received: "+a.Tag)
-	doc := documents.NewAnnotation(a)
-	meta, err := collection.CreateDocument(ctx, doc)
-	if err != nil {
-		return err
+	// Quarantined annotations are kept apart from the graph so they are never scored
+	if c.verifier != nil && c.verifier.Action() == config.QuarantineUnverified {
+		exists, err = db.CollectionExists(ctx, documents.CollectionQuarantine)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			c.logger.Write(slog.LevelDebug, "creating collection "+documents.CollectionQuarantine)
+			_, err = db.CreateCollection(ctx, documents.CollectionQuarantine, nil)
+			if err != nil {
+				return err
+			}
+		}
 	}
-	b, _ := json.Marshal(meta)
-	c.logger.Write(slog.LevelDebug, "annotation document created: "+string(b))
 	return nil
 }
 
-func (c *arangoClient) createDataDocument(ctx context.Context, documentKey string, collection driver.Collection) error {
-	exists, err := collection.DocumentExists(ctx, documentKey)
+// extendGraph adds any edge or vertex collections present in config but missing from an existing graph. This allows
+// collections introduced by newer versions of the scoring apps to be added without recreating the graph.
+func (c *arangoClient) extendGraph(ctx context.Context, db driver.Database) error {
+	graph, err := db.Graph(ctx, c.cfg.GraphName)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		doc := documents.Data{
-			Key:       documentKey,
-			Timestamp: time.Now(),
+	for _, item := range c.cfg.Edges {
+		exists, err := graph.EdgeCollectionExists(ctx, item.CollectionName)
+		if err != nil {
+			return err
 		}
-		meta, err := collection.CreateDocument(ctx, doc)
+		if !exists {
+			c.logger.Write(slog.LevelDebug, "creating edge "+item.CollectionName)
+			_, err = graph.CreateEdgeCollection(ctx, item.CollectionName, driver.VertexConstraints{From: item.From, To: item.To})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, v := range c.cfg.Vertexes {
+		exists, err := graph.VertexCollectionExists(ctx, v)
 		if err != nil {
 			return err
 		}
-		b, _ := json.Marshal(meta)
-		c.logger.Write(slog.LevelDebug, "data document created: "+string(b))
+		if !exists {
+			c.logger.Write(slog.LevelDebug, "creating vertex "+v)
+			_, err = graph.CreateVertexCollection(ctx, v)
+			if err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
-func (c *arangoClient) createEdge(ctx context.Context, src string, target string, collectionName string, graph driver.Graph) error {
-	edge, _, err := graph.EdgeCollection(ctx, collectionName)
-	if err != nil {
-		return err
-	}
-	var meta driver.DocumentMeta
-	if collectionName == documents.EdgeTrust {
-		edgeDoc := documents.Trust{
-			From: fmt.Sprintf("%s/%s", documents.VertexData, src),
-			To:   fmt.Sprintf("%s/%s", documents.VertexAnnotations, target),
+// screen maps the annotations to documents and, if signatures are verified, records whether each signature is valid.
+// Annotations that fail verification are left out if they are to be rejected or quarantined.
+func (c *arangoClient) screen(
+	ctx context.Context,
+	db driver.Database,
+	action message.SdkAction,
+	items []sdkContract.Annotation,
+) ([]documents.Annotation, error) {
+	var screened []documents.Annotation
+	for _, item := range items {
+		doc := documents.NewAnnotation(item, action)
+		if c.verifier == nil {
+			screened = append(screened, doc)
+			continue
 		}
-		meta, err = edge.CreateDocument(ctx, edgeDoc)
-	} else if collectionName == documents.EdgeLineage {
-		edgeDoc := documents.Lineage{
-			From: fmt.Sprintf("%s/%s", documents.VertexData, target),
-			To:   fmt.Sprintf("%s/%s", documents.VertexData, src),
+		valid := c.verifier.Verify(item)
+		doc.SignatureValid = &valid
+		if valid || c.verifier.Action() == config.RecordUnverified {
+			screened = append(screened, doc)
+			continue
+		}
+
+		c.logger.Write(slog.LevelWarn, fmt.Sprintf("signature of annotation %s from %s could not be verified, %s",
+			doc.Key, doc.Host, c.verifier.Action()))
+		if c.verifier.Action() == config.QuarantineUnverified {
+			quarantine, err := db.Collection(ctx, documents.CollectionQuarantine)
+			if err != nil {
+				return nil, err
+			}
+			// An annotation received again is already quarantined
+			_, err = quarantine.CreateDocument(driver.WithOverwriteMode(ctx, driver.OverwriteModeIgnore), doc)
+			if err != nil {
+				return nil, err
+			}
 		}
-		meta, err = edge.CreateDocument(ctx, edgeDoc)
-	}
-	if err != nil {
-		return err
 	}
-	b, _ := json.Marshal(meta)
-	c.logger.Write(slog.LevelDebug, "edge document created: "+string(b))
-	return nil
+	return screened, nil
 }
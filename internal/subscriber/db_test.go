@@ -0,0 +1,217 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package subscriber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/arangodb/go-driver"
+	sdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/message"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/internal/deadletter"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+func TestNewHandlers(t *testing.T) {
+	c := arangoClient{}
+	handlers := c.newHandlers()
+	actions := []message.SdkAction{
+		message.ActionCreate,
+		message.ActionMutate,
+		message.ActionTransit,
+		message.ActionPublish,
+		message.ActionBroadcast,
+		message.ActionEndStream,
+	}
+	for _, action := range actions {
+		if _, ok := handlers[action]; !ok {
+			t.Errorf("no handler for %s", action)
+		}
+	}
+	if len(handlers) != len(actions) {
+		t.Errorf("expected %d handlers, received %d", len(actions), len(handlers))
+	}
+}
+
+func TestHandle(t *testing.T) {
+	failing := errors.New("unavailable")
+	tests := []struct {
+		name         string
+		action       message.SdkAction
+		failing      error
+		unrecognized config.UnrecognizedAction
+		handled      bool
+		warned       bool
+		deadLettered bool
+	}{
+		{"create", message.ActionCreate, nil, config.IgnoreUnrecognized, true, false, false},
+		{"broadcast", message.ActionBroadcast, nil, config.IgnoreUnrecognized, true, false, false},
+		{"end-stream", message.ActionEndStream, nil, config.IgnoreUnrecognized, true, false, false},
+		{"handler failure", message.ActionMutate, failing, config.IgnoreUnrecognized, true, false, true},
+		{"unrecognized ignored", "rename", nil, config.IgnoreUnrecognized, false, false, false},
+		{"unrecognized logged", "rename", nil, config.LogUnrecognized, false, true, false},
+		{"unrecognized dead-lettered", "rename", nil, config.DeadLetterUnrecognized, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeLetterStore{}
+			logger := &fakeLogger{}
+			var handled []message.SdkAction
+			record := func(ctx context.Context, action message.SdkAction, content []byte) error {
+				handled = append(handled, action)
+				return tt.failing
+			}
+			c := arangoClient{
+				deadLetters:  deadletter.NewDeadLettersWithStore(store, nil, deadletter.SourceSubscriber, logger),
+				logger:       logger,
+				unrecognized: tt.unrecognized,
+				handlers:     make(map[message.SdkAction]handler),
+			}
+			for action := range c.newHandlers() {
+				c.handlers[action] = record
+			}
+			c.handle(context.Background(), message.SubscribeWrapper{Action: tt.action, Content: []byte("content")})
+
+			if (len(handled) == 1 && handled[0] == tt.action) != tt.handled {
+				t.Errorf("expected handled %v, received %v", tt.handled, handled)
+			}
+			if logger.warned() != tt.warned {
+				t.Errorf("expected warned %v, received %v", tt.warned, logger.entries)
+			}
+			if (len(store.letters) == 1) != tt.deadLettered {
+				t.Fatalf("expected dead-lettered %v, received %d dead letters", tt.deadLettered, len(store.letters))
+			}
+			if tt.deadLettered {
+				var item message.SubscribeWrapper
+				err := json.Unmarshal(store.letters[0].Payload, &item)
+				if err != nil || item.Action != tt.action || string(item.Content) != "content" {
+					t.Errorf("expected the message to be kept as received, received %s", store.letters[0].Payload)
+				}
+			}
+		})
+	}
+}
+
+func TestNewStream(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		action message.SdkAction
+		topic  string
+		opened bool
+		mode   driver.OverwriteMode
+		fails  bool
+	}{
+		{"broadcast", message.ActionBroadcast, "0.0.1234", true, driver.OverwriteModeReplace, false},
+		{"end-stream", message.ActionEndStream, "0.0.1234", false, driver.OverwriteModeUpdate, false},
+		{"no topic", message.ActionBroadcast, "", false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, mode, err := newStream(tt.action, []byte(tt.topic), now)
+			if (err != nil) != tt.fails {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tt.fails {
+				return
+			}
+			if stream.Topic != tt.topic || mode != tt.mode {
+				t.Errorf("unexpected stream %+v written with %s", stream, mode)
+			}
+			// A broadcast and an end-stream of the same topic write the same vertex
+			if other, _, _ := newStream(message.ActionBroadcast, []byte(tt.topic), now); stream.Key != other.Key {
+				t.Errorf("expected key %s, received %s", other.Key, stream.Key)
+			}
+			if (stream.Opened != nil) != tt.opened || (stream.Closed != nil) == tt.opened {
+				t.Errorf("expected opened %v, received %+v", tt.opened, stream)
+			}
+		})
+	}
+}
+
+func TestNewArangoClientUnrecognized(t *testing.T) {
+	dbConfig := config.DatabaseInfo{Type: config.DBArango, Config: config.ArangoConfig{
+		DatabaseName: "alvarium",
+		Provider:     sdkConfig.ServiceInfo{Host: "localhost", Port: 8529, Protocol: "http"},
+	}}
+	deadLetters := deadletter.NewDeadLettersWithStore(&fakeLetterStore{}, nil, deadletter.SourceSubscriber,
+		&fakeLogger{})
+	tests := []struct {
+		name        string
+		action      config.UnrecognizedAction
+		deadLetters *deadletter.DeadLetters
+		fails       bool
+	}{
+		{"ignore", config.IgnoreUnrecognized, nil, false},
+		{"log", config.LogUnrecognized, nil, false},
+		{"deadletter", config.DeadLetterUnrecognized, deadLetters, false},
+		{"deadletter without dead letters", config.DeadLetterUnrecognized, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewArangoClient(nil, nil, dbConfig, nil, tt.deadLetters, config.UnrecognizedInfo{Action: tt.action},
+				&fakeLogger{})
+			if (err != nil) != tt.fails {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
+
+// fakeLetterStore keeps dead letters in memory.
+type fakeLetterStore struct {
+	letters []documents.DeadLetter
+}
+
+func (s *fakeLetterStore) Init(ctx context.Context) error {
+	return nil
+}
+
+func (s *fakeLetterStore) Add(ctx context.Context, letter documents.DeadLetter) error {
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+func (s *fakeLetterStore) MarkReplayed(ctx context.Context, key string, replayed time.Time) error {
+	return nil
+}
+
+// fakeLogger keeps the level of every entry written.
+type fakeLogger struct {
+	entries []slog.Level
+}
+
+func (l *fakeLogger) Write(level slog.Level, message string, args ...any) {
+	l.entries = append(l.entries, level)
+}
+
+func (l *fakeLogger) Error(message string, args ...any) {
+	l.entries = append(l.entries, slog.LevelError)
+}
+
+func (l *fakeLogger) warned() bool {
+	for _, level := range l.entries {
+		if level == slog.LevelWarn {
+			return true
+		}
+	}
+	return false
+}
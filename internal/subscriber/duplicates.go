@@ -0,0 +1,69 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package subscriber
+
+import (
+	"encoding/json"
+
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+// received sorts the annotations of a list by whether they were persisted before. MQTT delivers messages at least
+// once, so the same AnnotationList can arrive again after a reconnect.
+type received struct {
+	fresh      []documents.Annotation // fresh holds annotations not persisted before, or whose content has changed since
+	changed    int                    // changed counts the fresh annotations replacing a persisted one with different content
+	duplicates []documents.Annotation // duplicates holds annotations identical to a persisted one, with the redelivery counted
+}
+
+// classify compares the incoming annotations with those already persisted under the same keys. An annotation
+// repeated within the list is only considered once.
+func classify(incoming []documents.Annotation, existing map[string]documents.Annotation) received {
+	var r received
+	seen := make(map[string]bool)
+	for _, a := range incoming {
+		if seen[a.Key] {
+			continue
+		}
+		seen[a.Key] = true
+
+		persisted, ok := existing[a.Key]
+		switch {
+		case !ok:
+			r.fresh = append(r.fresh, a)
+		case sameAnnotation(a, persisted):
+			persisted.Redelivered++
+			r.duplicates = append(r.duplicates, persisted)
+		default:
+			r.fresh = append(r.fresh, a)
+			r.changed++
+		}
+	}
+	return r
+}
+
+// sameAnnotation indicates whether two annotations have the same content, ignoring how often they were redelivered.
+func sameAnnotation(a documents.Annotation, b documents.Annotation) bool {
+	a.Redelivered, b.Redelivered = 0, 0
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(x) == string(y)
+}
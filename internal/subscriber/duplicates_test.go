@@ -0,0 +1,79 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package subscriber
+
+import (
+	"testing"
+	"time"
+
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+func TestClassify(t *testing.T) {
+	now := time.Now()
+	a := documents.Annotation{Key: "1", DataRef: "data", Kind: "tpm", IsSatisfied: true, Timestamp: now}
+	b := documents.Annotation{Key: "2", DataRef: "data", Kind: "tls", IsSatisfied: true, Timestamp: now}
+	changed := b
+	changed.IsSatisfied = false
+	redelivered := a
+	redelivered.Redelivered = 2
+
+	tests := []struct {
+		name        string
+		incoming    []documents.Annotation
+		existing    map[string]documents.Annotation
+		fresh       []string
+		changed     int
+		duplicates  []string
+		redelivered int
+	}{
+		{"all new", []documents.Annotation{a, b}, nil, []string{"1", "2"}, 0, nil, 0},
+		{"all duplicates", []documents.Annotation{a, b},
+			map[string]documents.Annotation{"1": a, "2": b}, nil, 0, []string{"1", "2"}, 1},
+		{"partially persisted", []documents.Annotation{a, b},
+			map[string]documents.Annotation{"1": a}, []string{"2"}, 0, []string{"1"}, 1},
+		{"changed content", []documents.Annotation{a, changed},
+			map[string]documents.Annotation{"1": a, "2": b}, []string{"2"}, 1, []string{"1"}, 1},
+		{"counted before", []documents.Annotation{a},
+			map[string]documents.Annotation{"1": redelivered}, nil, 0, []string{"1"}, 3},
+		{"repeated within list", []documents.Annotation{a, a}, nil, []string{"1"}, 0, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := classify(tt.incoming, tt.existing)
+			if !sameKeys(r.fresh, tt.fresh) || r.changed != tt.changed {
+				t.Errorf("unexpected fresh annotations %+v, changed %d", r.fresh, r.changed)
+			}
+			if !sameKeys(r.duplicates, tt.duplicates) {
+				t.Fatalf("unexpected duplicates %+v", r.duplicates)
+			}
+			if len(r.duplicates) > 0 && r.duplicates[0].Redelivered != tt.redelivered {
+				t.Errorf("expected redelivered %d, received %d", tt.redelivered, r.duplicates[0].Redelivered)
+			}
+		})
+	}
+}
+
+func sameKeys(annotations []documents.Annotation, keys []string) bool {
+	if len(annotations) != len(keys) {
+		return false
+	}
+	for i, a := range annotations {
+		if a.Key != keys[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -22,11 +22,18 @@ import (
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/message"
+	"github.com/project-alvarium/scoring-apps-go/internal/deadletter"
 	"github.com/project-alvarium/scoring-apps-go/internal/subscriber"
 	"github.com/project-alvarium/scoring-apps-go/internal/subscriber/streams/mqtt"
 )
 
-func NewSubscriber(cfg config.StreamInfo, pub chan message.SubscribeWrapper, key string, logger interfaces.Logger) (subscriber.Subscriber, error) {
+func NewSubscriber(
+	cfg config.StreamInfo,
+	pub chan message.SubscribeWrapper,
+	key string,
+	deadLetters *deadletter.DeadLetters,
+	logger interfaces.Logger,
+) (subscriber.Subscriber, error) {
 	var sub subscriber.Subscriber
 
 	switch cfg.Type {
@@ -35,7 +42,7 @@ func NewSubscriber(cfg config.StreamInfo, pub chan message.SubscribeWrapper, key
 		if !ok {
 			return nil, errors.New("unknown type cast to MqttConfig failed")
 		}
-		sub = mqtt.NewMqttSubscriber(endpoint, pub, logger)
+		sub = mqtt.NewMqttSubscriber(endpoint, pub, deadLetters, logger)
 	default:
 		return nil, errors.New(fmt.Sprintf("unrecognized stream provider type %s", cfg.Type))
 	}
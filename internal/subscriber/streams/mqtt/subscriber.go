@@ -26,17 +26,24 @@ import (
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/config"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/interfaces"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/message"
+	"github.com/project-alvarium/scoring-apps-go/internal/deadletter"
 	"github.com/project-alvarium/scoring-apps-go/internal/subscriber"
 )
 
 type mqttSubscriber struct {
-	chPub      chan message.SubscribeWrapper
-	endpoint   config.MqttConfig
-	logger     interfaces.Logger
-	mqttClient MQTT.Client
+	chPub       chan message.SubscribeWrapper
+	deadLetters *deadletter.DeadLetters // deadLetters keeps the payloads that cannot be decoded, if enabled
+	endpoint    config.MqttConfig
+	logger      interfaces.Logger
+	mqttClient  MQTT.Client
 }
 
-func NewMqttSubscriber(endpoint config.MqttConfig, pub chan message.SubscribeWrapper, logger interfaces.Logger) subscriber.Subscriber {
+func NewMqttSubscriber(
+	endpoint config.MqttConfig,
+	pub chan message.SubscribeWrapper,
+	deadLetters *deadletter.DeadLetters,
+	logger interfaces.Logger,
+) subscriber.Subscriber {
 	// create MQTT options
 	opts := MQTT.NewClientOptions()
 	opts.AddBroker(endpoint.Provider.Uri())
@@ -46,10 +53,11 @@ func NewMqttSubscriber(endpoint config.MqttConfig, pub chan message.SubscribeWra
 	opts.SetCleanSession(endpoint.Cleanness)
 
 	var subscriber = mqttSubscriber{
-		chPub:      pub,
-		endpoint:   endpoint,
-		logger:     logger,
-		mqttClient: MQTT.NewClient(opts),
+		chPub:       pub,
+		deadLetters: deadLetters,
+		endpoint:    endpoint,
+		logger:      logger,
+		mqttClient:  MQTT.NewClient(opts),
 	}
 	// no error to report
 	return &subscriber
@@ -140,6 +148,9 @@ func (s *mqttSubscriber) mqttMessageHandler(client MQTT.Client, mqttMsg MQTT.Mes
 	err := json.Unmarshal(mqttMsg.Payload(), &wrapped)
 	if err != nil {
 		s.logger.Error(err.Error())
+		if s.deadLetters != nil {
+			s.deadLetters.Record(context.Background(), mqttMsg.Topic(), mqttMsg.Payload(), err)
+		}
 	} else {
 		s.chPub <- wrapped
 	}
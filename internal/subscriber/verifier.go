@@ -0,0 +1,137 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package subscriber
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dustinxie/ecc"
+	sdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	sdkContract "github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+)
+
+// Verifier checks the signatures of incoming annotations against the configured public keys.
+type Verifier struct {
+	action config.VerificationAction
+	keys   []publicKey
+}
+
+// publicKey verifies a signature over content the way the SDK's signature provider for the key type does, without
+// reading the key file again.
+type publicKey func(content, signature []byte) bool
+
+// NewVerifier returns nil if no public keys are configured, in which case signatures are not verified. The keys are
+// read and decoded once, so an unreadable or malformed key fails here rather than on every annotation.
+func NewVerifier(cfg config.VerificationInfo) (*Verifier, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, nil
+	}
+	v := Verifier{action: cfg.Action}
+	for _, k := range cfg.Keys {
+		key, err := loadKey(k)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = append(v.keys, key)
+	}
+	return &v, nil
+}
+
+// Action is what is done with the annotations that fail verification.
+func (v *Verifier) Action() config.VerificationAction {
+	return v.action
+}
+
+// Verify indicates whether any of the public keys verifies the signature of the annotation. Annotations are signed on
+// their JSON representation before the signature is set, so the signature is cleared before the content is derived.
+func (v *Verifier) Verify(a sdkContract.Annotation) bool {
+	signature := a.Signature
+	if signature == "" {
+		return false
+	}
+	a.Signature = ""
+	b, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	for _, verify := range v.keys {
+		if verify(b, []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadKey reads the public key file and decodes the key in the format the SDK writes for its type.
+func loadKey(k sdkConfig.KeyInfo) (publicKey, error) {
+	b, err := os.ReadFile(k.Path)
+	if err != nil {
+		return nil, err
+	}
+	invalid := fmt.Errorf("%s is not a valid %s public key", k.Path, k.Type)
+	switch k.Type {
+	case sdkContract.KeyEd25519:
+		// The key and the signature are hex encoded
+		key, err := hex.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, invalid
+		}
+		return func(content, signature []byte) bool {
+			sig, err := hex.DecodeString(string(signature))
+			return err == nil && ed25519.Verify(key, content, sig)
+		}, nil
+	case sdkContract.KeyEcdsaSecp256k1:
+		// The key is a hex encoded compressed point and the signature is over the SHA-256 hash of the content
+		compressed, err := hex.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil {
+			return nil, invalid
+		}
+		x, y := ecc.UnmarshalCompressed(ecc.P256k1(), compressed)
+		if x == nil {
+			return nil, invalid
+		}
+		key := &ecdsa.PublicKey{Curve: ecc.P256k1(), X: x, Y: y}
+		return func(content, signature []byte) bool {
+			hash := sha256.Sum256(content)
+			return ecc.VerifyBytes(key, hash[:], signature, ecc.Normal)
+		}, nil
+	case sdkContract.KeyEcdsaX509:
+		// The key is PKIX encoded and the signature is ASN.1 DER encoded over the SHA-256 hash of the content, as the SDK
+		// signs with ecdsa.SignASN1
+		parsed, err := x509.ParsePKIXPublicKey(b)
+		if err != nil {
+			return nil, invalid
+		}
+		key, ok := parsed.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, invalid
+		}
+		return func(content, signature []byte) bool {
+			hash := sha256.Sum256(content)
+			return ecdsa.VerifyASN1(key, hash[:], signature)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unrecognized key algorithm value %s", k.Type)
+	}
+}
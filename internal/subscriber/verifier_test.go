@@ -0,0 +1,205 @@
+/*******************************************************************************
+ * Copyright 2024 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package subscriber
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dustinxie/ecc"
+	sdkConfig "github.com/project-alvarium/alvarium-sdk-go/pkg/config"
+	sdkContract "github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/internal/config"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+)
+
+func TestVerifierVerify(t *testing.T) {
+	dir := t.TempDir()
+	known := writeKey(t, dir, "known.key")
+	writeKey(t, dir, "other.key")
+
+	annotation := sdkContract.Annotation{
+		Id:          documents.NewULID(),
+		Key:         "key",
+		Host:        "host",
+		Kind:        sdkContract.AnnotationTPM,
+		IsSatisfied: true,
+		Timestamp:   time.Now(),
+	}
+	b, _ := json.Marshal(annotation)
+	signed := annotation
+	signed.Signature = hex.EncodeToString(ed25519.Sign(known, b))
+	tampered := signed
+	tampered.IsSatisfied = false
+
+	// The SDK signs the SHA-256 hash of the content with secp256k1 and sets the raw signature bytes
+	hash := sha256.Sum256(b)
+	sig, err := ecc.SignBytes(writeSecp256k1Key(t, dir, "known.secp256k1"), hash[:], ecc.Normal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secp256k1 := annotation
+	secp256k1.Signature = string(sig)
+	secp256k1Tampered := secp256k1
+	secp256k1Tampered.IsSatisfied = false
+	// A signature made with a key that is not configured
+	sig, err = ecc.SignBytes(writeSecp256k1Key(t, dir, "other.secp256k1"), hash[:], ecc.Normal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secp256k1Unknown := annotation
+	secp256k1Unknown.Signature = string(sig)
+
+	// The SDK signs the SHA-256 hash of the content with the x509 key as ASN.1 and sets the raw signature bytes
+	sig, err = ecdsa.SignASN1(rand.Reader, writeX509Key(t, dir, "known.x509"), hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	x509Signed := annotation
+	x509Signed.Signature = string(sig)
+	x509Tampered := x509Signed
+	x509Tampered.IsSatisfied = false
+
+	v, err := NewVerifier(config.VerificationInfo{Keys: []sdkConfig.KeyInfo{
+		{Type: sdkContract.KeyEd25519, Path: filepath.Join(dir, "other.key.pub")},
+		{Type: sdkContract.KeyEd25519, Path: filepath.Join(dir, "known.key.pub")},
+		{Type: sdkContract.KeyEcdsaSecp256k1, Path: filepath.Join(dir, "known.secp256k1.pub")},
+		{Type: sdkContract.KeyEcdsaX509, Path: filepath.Join(dir, "known.x509.pub")},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		annotation sdkContract.Annotation
+		expected   bool
+	}{
+		{"valid", signed, true},
+		{"tampered", tampered, false},
+		{"unsigned", annotation, false},
+		{"valid secp256k1", secp256k1, true},
+		{"tampered secp256k1", secp256k1Tampered, false},
+		{"unknown secp256k1", secp256k1Unknown, false},
+		{"valid x509", x509Signed, true},
+		{"tampered x509", x509Tampered, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := v.Verify(tt.annotation); result != tt.expected {
+				t.Errorf("expected %v, received %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "known.key")
+	writeSecp256k1Key(t, dir, "known.secp256k1")
+	writeX509Key(t, dir, "known.x509")
+	short := filepath.Join(dir, "short.pub")
+	if err := os.WriteFile(short, []byte("abcd"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Not a point on the curve
+	offCurve := filepath.Join(dir, "offcurve.pub")
+	if err := os.WriteFile(offCurve, []byte("05"+hex.EncodeToString(make([]byte, 32))), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    []sdkConfig.KeyInfo
+		enabled bool
+		fails   bool
+	}{
+		{"no keys", nil, false, false},
+		{"valid key", []sdkConfig.KeyInfo{{Type: sdkContract.KeyEd25519, Path: filepath.Join(dir, "known.key.pub")}}, true, false},
+		{"missing file", []sdkConfig.KeyInfo{{Type: sdkContract.KeyEd25519, Path: filepath.Join(dir, "missing.pub")}}, false, true},
+		{"wrong length", []sdkConfig.KeyInfo{{Type: sdkContract.KeyEd25519, Path: short}}, false, true},
+		{"valid secp256k1 key", []sdkConfig.KeyInfo{{Type: sdkContract.KeyEcdsaSecp256k1, Path: filepath.Join(dir, "known.secp256k1.pub")}}, true, false},
+		{"invalid secp256k1 key", []sdkConfig.KeyInfo{{Type: sdkContract.KeyEcdsaSecp256k1, Path: offCurve}}, false, true},
+		{"valid x509 key", []sdkConfig.KeyInfo{{Type: sdkContract.KeyEcdsaX509, Path: filepath.Join(dir, "known.x509.pub")}}, true, false},
+		{"invalid x509 key", []sdkConfig.KeyInfo{{Type: sdkContract.KeyEcdsaX509, Path: short}}, false, true},
+		{"unknown type", []sdkConfig.KeyInfo{{Type: "rsa", Path: filepath.Join(dir, "known.key.pub")}}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(config.VerificationInfo{Keys: tt.keys})
+			if (err != nil) != tt.fails {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if (v != nil) != tt.enabled {
+				t.Errorf("expected verification enabled %v, received %v", tt.enabled, v != nil)
+			}
+		})
+	}
+}
+
+// writeKey generates an ed25519 key pair and writes the hex encoded public key to name.pub in the directory.
+func writeKey(t *testing.T, dir string, name string) ed25519.PrivateKey {
+	pub, prv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, name+".pub"), []byte(hex.EncodeToString(pub)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prv
+}
+
+// writeSecp256k1Key generates a secp256k1 key pair and writes the hex encoded compressed public key to name.pub in the
+// directory.
+func writeSecp256k1Key(t *testing.T, dir string, name string) *ecdsa.PrivateKey {
+	prv, err := ecdsa.GenerateKey(ecc.P256k1(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := ecc.MarshalCompressed(ecc.P256k1(), prv.X, prv.Y)
+	err = os.WriteFile(filepath.Join(dir, name+".pub"), []byte(hex.EncodeToString(pub)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prv
+}
+
+// writeX509Key generates a P-256 key pair and writes the PKIX encoded public key to name.pub in the directory.
+func writeX509Key(t *testing.T, dir string, name string) *ecdsa.PrivateKey {
+	prv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&prv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, name+".pub"), pub, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prv
+}
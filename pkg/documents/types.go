@@ -15,11 +15,16 @@
 package documents
 
 import (
+	"encoding/json"
 	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/oklog/ulid/v2"
 	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/message"
+	"github.com/project-alvarium/scoring-apps-go/pkg/hashprovider"
 	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
 )
 
@@ -28,9 +33,17 @@ const (
 	EdgeScoring       string = "scoring"
 	EdgeTrust         string = "trust"
 	EdgeStack         string = "stack"
+	EdgeSupersedes    string = "supersedes"
+	EdgeWeighting     string = "weighting"
 	VertexAnnotations string = "annotations"
 	VertexData        string = "data"
+	VertexPolicies    string = "policies"
 	VertexScores      string = "scores"
+	VertexStreams     string = "streams"
+	// CollectionQuarantine holds the annotations whose signature could not be verified. It is not part of the graph.
+	CollectionQuarantine string = "quarantine"
+	// CollectionDeadLetters holds the messages that could not be processed. It is not part of the graph.
+	CollectionDeadLetters string = "deadletters"
 )
 
 // Data represents a document in the "data" vertex collection
@@ -39,6 +52,16 @@ type Data struct {
 	Timestamp time.Time `json:"timestamp,omitempty"` // Timestamp indicates when the document was created
 }
 
+// Stream represents a document in the "streams" vertex collection. A stream is opened when the SDK broadcasts its
+// topic and closed when the SDK ends it. Streams are standalone records without edges, since the annotations published
+// on a stream do not carry its topic.
+type Stream struct {
+	Key    string     `json:"_key,omitempty"`   // Key is derived from the topic
+	Topic  string     `json:"topic,omitempty"`  // Topic identifies the stream annotations are published on, such as a Hedera topic ID
+	Opened *time.Time `json:"opened,omitempty"` // Opened indicates when the topic was last broadcast
+	Closed *time.Time `json:"closed,omitempty"` // Closed indicates when the stream was ended, if it has been since it was opened
+}
+
 // Annotation represents a document in the "annotation" vertex collection
 type Annotation struct {
 	Key         string              `json:"_key,omitempty"`    // Key uniquely identifies the document in the database
@@ -51,10 +74,22 @@ type Annotation struct {
 	Signature   string              `json:"signature,omitempty"` // Signature contains the signature of the party making the annotation
 	IsSatisfied bool                `json:"isSatisfied"`         // IsSatisfied indicates whether the criteria defining the annotation were fulfilled
 	Timestamp   time.Time           `json:"timestamp,omitempty"` // Timestamp indicates when the annotation was created
+	// SignatureValid indicates whether the signature was verified against a known public key. It is not set if the
+	// subscriber does not verify signatures.
+	SignatureValid *bool `json:"signatureValid,omitempty"`
+	// Redelivered counts how many times the same annotation was received again after it was first persisted
+	Redelivered int `json:"redelivered,omitempty"`
+	// Action is the SDK action the annotation was received with, such as create or publish
+	Action message.SdkAction `json:"action,omitempty"`
+}
+
+// Verified indicates whether the signature of the annotation was checked and found to be valid.
+func (a Annotation) Verified() bool {
+	return a.SignatureValid != nil && *a.SignatureValid
 }
 
-// NewAnnotation will map an Alvarium SDK annotation into an Annotation document
-func NewAnnotation(a contracts.Annotation) Annotation {
+// NewAnnotation will map an Alvarium SDK annotation, received with the given action, into an Annotation document
+func NewAnnotation(a contracts.Annotation, action message.SdkAction) Annotation {
 	return Annotation{
 		Key:         a.Id.String(),
 		DataRef:     a.Key,
@@ -66,23 +101,99 @@ func NewAnnotation(a contracts.Annotation) Annotation {
 		Signature:   a.Signature,
 		IsSatisfied: a.IsSatisfied,
 		Timestamp:   a.Timestamp,
+		Action:      action,
 	}
 }
 
 // Score represents a document in the "score" vertex collection
 type Score struct {
-	Key        ulid.ULID           `json:"_key,omitempty"`      // Key uniquely identifies the document in the database
-	DataRef    string              `json:"dataRef,omitempty"`   // DataRef points to the key of the data being annotated
-	Passed     int                 `json:"score"`               // Passed indicates how many of the annotations for a given dataRef were Satisfied
-	Count      int                 `json:"count"`               // Count indicates the total number of annotations applicable to a dataRef
-	Policy     string              `json:"policy,omitempty"`    // Policy will indicate some version of the policy used to calculate confidence
-	Confidence float64             `json:"confidence"`          // Confidence is the percentage of trust in the dataRef
-	Timestamp  time.Time           `json:"timestamp,omitempty"` // Timestamp indicates when the score was calculated
-	Tag        []string            `json:"tag,omitempty"`
-	Layer      contracts.LayerType `json:"layer,omitempty"`
-}
-
-func NewScore(dataRef string, annotations []Annotation, policy policies.DcfPolicy, tagFieldScores map[string]Score, hostFieldScores map[string]Score) Score {
+	Key         ulid.ULID           `json:"_key,omitempty"`      // Key uniquely identifies the document in the database
+	DataRef     string              `json:"dataRef,omitempty"`   // DataRef points to the key of the data being annotated
+	Passed      int                 `json:"score"`               // Passed indicates how many of the annotations for a given dataRef were Satisfied
+	Count       int                 `json:"count"`               // Count indicates the total number of annotations applicable to a dataRef
+	Policy      string              `json:"policy,omitempty"`    // Policy will indicate some version of the policy used to calculate confidence
+	Strategy    string              `json:"strategy,omitempty"`  // Strategy indicates the scoring strategy used to calculate confidence
+	Confidence  float64             `json:"confidence"`          // Confidence is the percentage of trust in the dataRef
+	Band        string              `json:"band,omitempty"`      // Band is the confidence band of the policy the confidence falls in
+	Timestamp   time.Time           `json:"timestamp,omitempty"` // Timestamp indicates when the score was calculated
+	Tag         []string            `json:"tag,omitempty"`
+	Layer       contracts.LayerType `json:"layer,omitempty"`
+	Partial     bool                `json:"partial,omitempty"`     // Partial indicates the score was calculated before all expected annotations were received
+	Version     int                 `json:"version"`               // Version increases each time the dataRef is scored again
+	Current     bool                `json:"current"`               // Current indicates this is the most recent version of the score for the dataRef
+	Explanation *Explanation        `json:"explanation,omitempty"` // Explanation records how the confidence was arrived at
+	Missing     []MissingScore      `json:"missing,omitempty"`     // Missing lists the lower layer scores that could not be found
+	Parents     []ParentScore       `json:"parents,omitempty"`     // Parents lists the scores of the data this data was mutated from
+}
+
+// ParentScore identifies the score of parent data that was folded into the confidence of data mutated from it
+type ParentScore struct {
+	DataRef    string  `json:"dataRef"`            // DataRef is the key of the parent data
+	ScoreKey   string  `json:"scoreKey,omitempty"` // ScoreKey is the key of the parent score
+	Confidence float64 `json:"confidence"`
+	Missing    bool    `json:"missing,omitempty"` // Missing is set if the parent has not been scored and was left out
+}
+
+// MissingScore records a lower layer score that could not be found while calculating a score and the penalty applied
+// in its place
+type MissingScore struct {
+	Field      string              `json:"field"`                // Field is the tag or host value that has no score
+	Layer      contracts.LayerType `json:"layer"`                // Layer is the lower layer that was searched
+	Penalty    string              `json:"penalty"`              // Penalty is the configured action taken for the missing score
+	Confidence float64             `json:"confidence,omitempty"` // Confidence is what stood in for the missing score, if anything did
+}
+
+// Explanation breaks a Score down into the inputs that produced its confidence
+type Explanation struct {
+	Policy      string                   `json:"policy,omitempty"`      // Policy is the name of the policy that supplied the weights
+	PolicyKey   string                   `json:"policyKey,omitempty"`   // PolicyKey identifies the version of the policy in the "policies" collection
+	Strategy    string                   `json:"strategy,omitempty"`    // Strategy is the scoring strategy that combined the inputs
+	Decay       string                   `json:"decay,omitempty"`       // Decay is the function used to reduce lower layer confidence by age
+	Propagation string                   `json:"propagation,omitempty"` // Propagation is the function used to fold in the confidence of parent data
+	Annotations []AnnotationContribution `json:"annotations"`           // Annotations lists the contribution of each annotation
+	LowerLayers []LowerLayerExplanation  `json:"lowerLayers,omitempty"` // LowerLayers describes each lower layer the score depends on
+	// DecidedWeights are the weights the decision rule of the policy chose for this data in place of the configured ones
+	DecidedWeights []policies.Weight `json:"decidedWeights,omitempty"`
+	// AbsentRequired lists the required annotation kinds that were not received at all, each of which capped the confidence
+	AbsentRequired []string `json:"absentRequired,omitempty"`
+}
+
+// LowerLayerExplanation describes the confidence a lower layer contributed to a score
+type LowerLayerExplanation struct {
+	Layer      contracts.LayerType `json:"layer"`
+	JoinOn     string              `json:"joinOn"`               // JoinOn is the annotation field matched against the tags of the lower layer scores
+	Counted    bool                `json:"counted"`              // Counted indicates whether the lower layer influenced the score
+	Confidence float64             `json:"confidence,omitempty"` // Confidence is the average confidence multiplied in, after decay
+	Scores     []LowerLayerScore   `json:"scores,omitempty"`     // Scores are the lower layer scores found for the annotations
+}
+
+// AnnotationContribution describes how a single annotation contributed to a score. The contribution is the share of
+// the total weight the annotation adds to the weighted pass ratio, so it is zero when the annotation was not satisfied.
+// A required annotation that was not satisfied also caps the confidence.
+type AnnotationContribution struct {
+	Key          string            `json:"key,omitempty"`
+	Kind         string            `json:"type,omitempty"`
+	Action       message.SdkAction `json:"action,omitempty"` // Action is the SDK action the annotation was received with
+	Weight       float64           `json:"weight"`
+	Required     bool              `json:"required,omitempty"` // Required indicates the annotation must be satisfied
+	Vetoed       bool              `json:"vetoed,omitempty"`   // Vetoed indicates the confidence was capped because a required annotation was not satisfied
+	IsSatisfied  bool              `json:"isSatisfied"`
+	Unverified   bool              `json:"unverified,omitempty"` // Unverified indicates the annotation counted as unsatisfied because its signature was not verified
+	Contribution float64           `json:"contribution"`
+}
+
+// LowerLayerScore identifies a lower layer score that was used while calculating a score
+type LowerLayerScore struct {
+	Field       string              `json:"field"`              // Field is the tag or host value used to find the score
+	ScoreKey    string              `json:"scoreKey,omitempty"` // ScoreKey is the key of the lower layer score
+	Layer       contracts.LayerType `json:"layer,omitempty"`
+	Confidence  float64             `json:"confidence"`  // Confidence is the confidence of the lower layer score as calculated
+	DecayFactor float64             `json:"decayFactor"` // DecayFactor is the multiplier applied to the confidence because of its age
+}
+
+// NewScore maps the outcome of a scoring strategy into a Score document. The confidence is rounded to two decimal
+// places.
+func NewScore(dataRef string, annotations []Annotation, policy string, strategy string, confidence float64) Score {
 	// All incoming annotations will have the same layer value
 	layer := annotations[0].Layer
 
@@ -91,71 +202,43 @@ func NewScore(dataRef string, annotations []Annotation, policy policies.DcfPolic
 	uniqueTags := make(map[string]bool)
 	var scoreTag []string
 
+	var passed int
 	for _, annotation := range annotations {
 		if !uniqueTags[annotation.Tag] {
 			uniqueTags[annotation.Tag] = true
 			scoreTag = append(scoreTag, annotation.Tag)
 		}
-	}
-
-	var totalTagFieldConfidence, totalHostFieldConfidence float64
-	var totalWeight, passedWeight float32
-	var passed int
-	for _, a := range annotations {
-		w := policy.FetchWeight(a.Kind)
-		totalWeight += float32(w.Value)
-		if a.IsSatisfied {
+		if annotation.IsSatisfied {
 			passed++
-			passedWeight += float32(w.Value)
 		}
-
-		tagScore, exists := tagFieldScores[a.Tag]
-		if exists {
-			totalTagFieldConfidence += tagScore.Confidence
-		}
-
-		hostFieldScore, exists := hostFieldScores[a.Host]
-		if exists {
-			totalHostFieldConfidence += hostFieldScore.Confidence
-		}
-	}
-
-	averageTagFieldConfidence := totalTagFieldConfidence / float64(len(annotations))
-	averageHostFieldConfidence := totalHostFieldConfidence / float64(len(annotations))
-
-	confidence := float64(passedWeight / totalWeight)
-
-	// The confidence should be influenced by the lower layers if they have a calculated confidence
-	if averageTagFieldConfidence > 0 {
-		confidence *= averageTagFieldConfidence
-	}
-	if averageHostFieldConfidence > 0 {
-		confidence *= averageHostFieldConfidence
 	}
-	confidence = math.Round(confidence*100) / 100
 
 	s := Score{
 		Key:        NewULID(),
 		DataRef:    dataRef,
 		Passed:     passed,
 		Count:      len(annotations),
-		Policy:     policy.Name,
-		Confidence: confidence,
+		Policy:     policy,
+		Strategy:   strategy,
+		Confidence: math.Round(confidence*100) / 100,
 		Timestamp:  time.Now(),
 		Layer:      layer,
 		Tag:        scoreTag,
+		Current:    true,
 	}
 	return s
 }
 
 // Trust represents a document in the "trust" edge collection
 type Trust struct {
+	Key  string `json:"_key,omitempty"` // Key is the key of the annotation, so that an annotation is linked only once
 	From string `json:"_from"`
 	To   string `json:"_to"`
 }
 
 // Lineage represents a document in the "lineage" edge collection
 type Lineage struct {
+	Key  string `json:"_key,omitempty"` // Key is derived from both data keys, so that two versions are linked only once
 	From string `json:"_from"`
 	To   string `json:"_to"`
 }
@@ -166,8 +249,68 @@ type Scoring struct {
 	To   string `json:"_to"`
 }
 
-// Scoring represents a document in the "stack" edge collection
+// Stack represents a document in the "stack" edge collection
 type Stack struct {
 	From string `json:"_from"`
 	To   string `json:"_to"`
 }
+
+// Supersedes represents a document in the "supersedes" edge collection, linking a score to the version it replaced
+type Supersedes struct {
+	From string `json:"_from"`
+	To   string `json:"_to"`
+}
+
+// Weighting represents a document in the "weighting" edge collection, linking a score to the version of the policy
+// whose weights produced it
+type Weighting struct {
+	From string `json:"_from"`
+	To   string `json:"_to"`
+}
+
+// Policy represents a document in the "policies" vertex collection. Each distinct body of a policy is stored once,
+// keyed by the hash of its content, so editing a policy adds a new version rather than replacing the old one. Reverting
+// to an earlier body reuses its version and moves its activation time forward.
+type Policy struct {
+	Key               string            `json:"_key,omitempty"`              // Key is the hash of the policy content
+	Name              string            `json:"classifier"`                  // Name is the classifier of the policy
+	Weights           []policies.Weight `json:"items"`                       // Weights are sorted by annotation key
+	Bands             []policies.Band   `json:"bands,omitempty"`             // Bands are the confidence bands, if the policy defines any
+	RequireSignatures bool              `json:"requireSignatures,omitempty"` // RequireSignatures indicates unverified annotations counted as unsatisfied
+	Timestamp         time.Time         `json:"timestamp"`                   // Timestamp is when the version was first stored
+	Activated         time.Time         `json:"activated"`                   // Activated is when the version last became the one in use
+}
+
+// NewPolicy creates the Policy document for a policy. The weights are sorted before hashing, so the order in which they
+// were configured does not produce a new version.
+func NewPolicy(p policies.DcfPolicy) Policy {
+	weights := slices.Clone(p.Weights)
+	slices.SortFunc(weights, func(a, b policies.Weight) int {
+		return strings.Compare(a.AnnotationKey, b.AnnotationKey)
+	})
+	sorted := p
+	sorted.Weights = weights
+	b, _ := json.Marshal(sorted)
+	now := time.Now()
+	return Policy{
+		Key:               hashprovider.DeriveHash(b),
+		Name:              p.Name,
+		Weights:           weights,
+		Bands:             p.Bands,
+		RequireSignatures: p.RequireSignatures,
+		Timestamp:         now,
+		Activated:         now,
+	}
+}
+
+// DeadLetter represents a document in the "deadletters" collection. It keeps a message that could not be decoded or
+// processed so that it can be inspected and replayed once the cause has been fixed.
+type DeadLetter struct {
+	Key       ulid.ULID  `json:"_key,omitempty"`     // Key uniquely identifies the document in the database
+	Source    string     `json:"source"`             // Source is the application that received the message
+	Topic     string     `json:"topic,omitempty"`    // Topic is where the message was received, if known
+	Payload   []byte     `json:"payload"`            // Payload is the message as it was received
+	Error     string     `json:"error"`              // Error explains why the message could not be processed
+	Timestamp time.Time  `json:"timestamp"`          // Timestamp is when the message was dead-lettered
+	Replayed  *time.Time `json:"replayed,omitempty"` // Replayed is when the message was last replayed, if it has been
+}
@@ -20,6 +20,7 @@ import (
 	"strings"
 )
 
+// DeriveHash returns the SHA-256 hash of the data as upper case hex. It keys documents derived from their content.
 func DeriveHash(data []byte) string {
 	h := crypto.Sum256(data)
 	hashEncoded := make([]byte, hex.EncodedLen(len(h)))
@@ -14,6 +14,16 @@
 
 package msg
 
+import (
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+)
+
+// ScoreCalculatedType is the message type of a ScoreCalculated event
+const ScoreCalculatedType string = "ScoreCalculated"
+
 type PublishWrapper struct {
 	MessageType string      `json:"messageType,omitempty"`
 	Content     interface{} `json:"content,omitempty"`
@@ -23,3 +33,15 @@ type SubscribeWrapper struct {
 	MessageType string `json:"messageType,omitempty"`
 	Content     []byte `json:"content,omitempty"`
 }
+
+// ScoreCalculated is published by the calculator every time a score has been persisted. It is carried as the JSON
+// encoded content of a PublishWrapper.
+type ScoreCalculated struct {
+	DataRef    string              `json:"dataRef"`
+	Layer      contracts.LayerType `json:"layer,omitempty"`
+	Confidence float64             `json:"confidence"`
+	Band       string              `json:"band,omitempty"`
+	Policy     string              `json:"policy,omitempty"`
+	ScoreKey   ulid.ULID           `json:"scoreKey"`
+	Timestamp  time.Time           `json:"timestamp"`
+}
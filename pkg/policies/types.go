@@ -16,41 +16,126 @@ package policies
 
 import (
 	"encoding/json"
+	"math"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/message"
+)
+
+const (
+	minWeight float64 = 0  // Weights must be greater than minWeight
+	maxWeight float64 = 10 // Weights above maxWeight are reduced to it
+	defWeight float64 = 1  // defWeight is used when a weight is not set
+	maxCap    float64 = 1  // Caps above maxCap are reduced to it
 )
 
+// DefaultBands are used to label confidence scores when a policy does not define its own bands.
+var DefaultBands = []Band{
+	{Name: "trusted", Min: 0.8},
+	{Name: "suspect", Min: 0.5},
+	{Name: "untrusted", Min: 0},
+}
+
 // DcfPolicy is a struct for defining behaviors of the DCF
 type DcfPolicy struct {
-	Name    string   `json:"classifier,omitempty"` // Name uniquely identifies the policy
-	Weights []Weight `json:"items,omitempty"`      // Weights contains all of the individual annotation weights
+	Name              string   `json:"classifier,omitempty"`        // Name uniquely identifies the policy
+	Weights           []Weight `json:"items,omitempty"`             // Weights contains all of the individual annotation weights
+	Bands             []Band   `json:"bands,omitempty"`             // Bands label ranges of confidence, DefaultBands are used if empty
+	RequireSignatures bool     `json:"requireSignatures,omitempty"` // RequireSignatures treats annotations without a verified signature as unsatisfied
 }
 
-func (p *DcfPolicy) FetchWeight(key string) Weight {
-	w := Weight{}
+// Band labels the confidence scores from Min up to the Min of the next band above it
+type Band struct {
+	Name string  `json:"name"`
+	Min  float64 `json:"min"`
+}
+
+// Band returns the name of the band with the highest minimum the confidence reaches, or an empty string if the
+// confidence is below every band.
+func (p *DcfPolicy) Band(confidence float64) string {
+	bands := p.Bands
+	if len(bands) == 0 {
+		bands = DefaultBands
+	}
 
+	name := ""
+	highest := math.Inf(-1)
+	for _, b := range bands {
+		if confidence >= b.Min && b.Min > highest {
+			name = b.Name
+			highest = b.Min
+		}
+	}
+	return name
+}
+
+// FetchWeight returns the weight of the annotation key for annotations of the given layer that were received with the
+// given SDK action. Any override for the layer is applied first, then any override for the action. The returned weight
+// has no overrides of its own.
+func (p *DcfPolicy) FetchWeight(key string, layer contracts.LayerType, action message.SdkAction) Weight {
+	w := Weight{}
 	for _, item := range p.Weights {
 		if item.AnnotationKey == key {
 			w = item
 			break
 		}
 	}
+	if o, ok := w.Layers[layer]; ok {
+		w.override(o)
+	}
+	if o, ok := w.Actions[action]; ok {
+		w.override(o)
+	}
+	w.Layers = nil
+	w.Actions = nil
+
 	// catch in case the provided key was not found in the defined list of Weights
-	if w.Value == 0 {
+	if w.Value <= minWeight {
 		w.AnnotationKey = key
-		w.Value = 1
+		w.Value = defWeight
 	}
 	return w
 }
 
 // Weight defines the weighting given to an individual annotation result, used when calculating a confidence score
 type Weight struct {
-	AnnotationKey string `json:"key,omitempty"`   // AnnotationKey indicates the applicable annotation type
-	Value         int    `json:"value,omitempty"` // Value indicates the relative importance of the annotation from 1 to 10.
+	AnnotationKey string                              `json:"key,omitempty"`      // AnnotationKey indicates the applicable annotation type
+	Value         float64                             `json:"value,omitempty"`    // Value indicates the relative importance of the annotation, greater than 0 and up to 10.
+	Required      bool                                `json:"required,omitempty"` // Required limits the confidence to Cap when the annotation is not satisfied
+	Cap           float64                             `json:"cap,omitempty"`      // Cap is the highest confidence allowed when a required annotation is not satisfied, 0 by default
+	Layers        map[contracts.LayerType]LayerWeight `json:"layers,omitempty"`   // Layers overrides the weight for annotations of a layer
+	Actions       map[message.SdkAction]LayerWeight   `json:"actions,omitempty"`  // Actions overrides the weight for annotations received with an SDK action
+}
+
+// override replaces the fields of the weight that are set in o.
+func (w *Weight) override(o LayerWeight) {
+	if o.Value > minWeight {
+		w.Value = o.Value
+	}
+	if o.Required != nil {
+		w.Required = *o.Required
+	}
+	if o.Cap != nil {
+		w.Cap = *o.Cap
+	}
+}
+
+// LayerWeight overrides a Weight for the annotations of one layer, or those received with one SDK action. Only the
+// fields that are set are overridden.
+type LayerWeight struct {
+	Value    float64  `json:"value,omitempty"`
+	Required *bool    `json:"required,omitempty"`
+	Cap      *float64 `json:"cap,omitempty"`
 }
 
 func (w *Weight) UnmarshalJSON(data []byte) (err error) {
 	type Alias struct {
-		AnnotationKey string `json:"key,omitempty"`
-		Value         int    `json:"value,omitempty"`
+		AnnotationKey string                              `json:"key,omitempty"`
+		Value         float64                             `json:"value,omitempty"`
+		Required      bool                                `json:"required,omitempty"`
+		Cap           float64                             `json:"cap,omitempty"`
+		Layers        map[contracts.LayerType]LayerWeight `json:"layers,omitempty"`
+		Actions       map[message.SdkAction]LayerWeight   `json:"actions,omitempty"`
 	}
 	a := Alias{}
 	// Error with unmarshaling
@@ -58,12 +143,48 @@ func (w *Weight) UnmarshalJSON(data []byte) (err error) {
 		return err
 	}
 
-	if a.Value < 1 {
-		a.Value = 1
-	} else if a.Value > 10 {
-		a.Value = 10
+	a.Value = clampWeight(a.Value, defWeight)
+	for layer, o := range a.Layers {
+		a.Layers[layer] = o.clamp()
+	}
+	for action, o := range a.Actions {
+		a.Actions[action] = o.clamp()
 	}
 	w.AnnotationKey = a.AnnotationKey
 	w.Value = a.Value
+	w.Required = a.Required
+	w.Cap = clampCap(a.Cap)
+	w.Layers = a.Layers
+	w.Actions = a.Actions
 	return nil
 }
+
+// clamp limits the overridden value and cap to their allowed ranges.
+func (o LayerWeight) clamp() LayerWeight {
+	o.Value = clampWeight(o.Value, 0)
+	if o.Cap != nil {
+		c := clampCap(*o.Cap)
+		o.Cap = &c
+	}
+	return o
+}
+
+// clampWeight limits a weight to the allowed range. Weights that are not set, or not greater than zero, are replaced
+// by the default.
+func clampWeight(value float64, def float64) float64 {
+	if value <= minWeight {
+		return def
+	} else if value > maxWeight {
+		return maxWeight
+	}
+	return value
+}
+
+func clampCap(value float64) float64 {
+	if value < 0 {
+		return 0
+	} else if value > maxCap {
+		return maxCap
+	}
+	return value
+}
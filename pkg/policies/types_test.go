@@ -16,7 +16,11 @@ package policies
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/message"
 )
 
 func TestWeightUnmarshal(t *testing.T) {
@@ -34,6 +38,11 @@ func TestWeightUnmarshal(t *testing.T) {
 		Value:         100,
 	}
 
+	weightHalf := Weight{
+		AnnotationKey: "half",
+		Value:         0.5,
+	}
+
 	tests := []struct {
 		name string
 		w    Weight
@@ -41,6 +50,7 @@ func TestWeightUnmarshal(t *testing.T) {
 		{"weight normal", weightOK},
 		{"weight value empty", weightMin},
 		{"weight value too high", weightMax},
+		{"weight value fractional", weightHalf},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -60,6 +70,72 @@ func TestWeightUnmarshal(t *testing.T) {
 				if x.Value != 10 {
 					t.Errorf("expected Value of 10, received %v", x.Value)
 				}
+			case "half":
+				if x.Value != 0.5 {
+					t.Errorf("expected Value of 0.5, received %v", x.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchWeight(t *testing.T) {
+	var p DcfPolicy
+	err := json.Unmarshal([]byte(`{
+		"classifier": "default",
+		"items": [
+			{"key": "tpm", "value": 2, "required": true, "layers": {"os": {"value": 0.5, "required": false}}},
+			{"key": "tls", "value": 1.5, "required": true, "cap": 2, "layers": {"app": {"cap": 0.25}},
+				"actions": {"publish": {"value": 3, "cap": 0.5}}}
+		]
+	}`), &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		layer    contracts.LayerType
+		action   message.SdkAction
+		expected Weight
+	}{
+		{"required", "tpm", contracts.Application, message.ActionCreate, Weight{AnnotationKey: "tpm", Value: 2, Required: true}},
+		{"layer override", "tpm", contracts.Os, message.ActionCreate, Weight{AnnotationKey: "tpm", Value: 0.5}},
+		{"capped", "tls", contracts.Os, message.ActionCreate, Weight{AnnotationKey: "tls", Value: 1.5, Required: true, Cap: 1}},
+		{"layer cap", "tls", contracts.Application, message.ActionCreate, Weight{AnnotationKey: "tls", Value: 1.5, Required: true, Cap: 0.25}},
+		{"action override", "tls", contracts.Os, message.ActionPublish, Weight{AnnotationKey: "tls", Value: 3, Required: true, Cap: 0.5}},
+		{"action after layer", "tls", contracts.Application, message.ActionPublish, Weight{AnnotationKey: "tls", Value: 3, Required: true, Cap: 0.5}},
+		{"not defined", "pki", contracts.Application, message.ActionPublish, Weight{AnnotationKey: "pki", Value: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := p.FetchWeight(tt.key, tt.layer, tt.action)
+			if !reflect.DeepEqual(w, tt.expected) {
+				t.Errorf("expected %+v, received %+v", tt.expected, w)
+			}
+		})
+	}
+}
+
+func TestBand(t *testing.T) {
+	custom := DcfPolicy{Bands: []Band{{Name: "high", Min: 0.9}, {Name: "low", Min: 0.2}}}
+	tests := []struct {
+		name       string
+		policy     DcfPolicy
+		confidence float64
+		expected   string
+	}{
+		{"default trusted", DcfPolicy{}, 0.8, "trusted"},
+		{"default suspect", DcfPolicy{}, 0.79, "suspect"},
+		{"default untrusted", DcfPolicy{}, 0, "untrusted"},
+		{"custom", custom, 0.5, "low"},
+		{"below every band", custom, 0.1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if band := tt.policy.Band(tt.confidence); band != tt.expected {
+				t.Errorf("expected band %s, received %s", tt.expected, band)
 			}
 		})
 	}
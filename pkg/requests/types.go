@@ -14,7 +14,14 @@
 
 package requests
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
+	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
+)
 
 type OpaWeightsRequest struct {
 	Classifier string `json:"class,omitempty"`
@@ -34,3 +41,42 @@ func (r *OpaWeightsRequest) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal(&requestAlias)
 }
+
+// OpaDecisionRequest asks OPA to decide the weights, or the confidence itself, for the annotations of a piece of data
+type OpaDecisionRequest struct {
+	Input OpaDecisionInput `json:"input"`
+}
+
+// OpaDecisionInput is the context of a score passed to the decision rule as its input
+type OpaDecisionInput struct {
+	Classifier  string                 `json:"class"`
+	DataRef     string                 `json:"dataRef"`
+	Layer       contracts.LayerType    `json:"layer"`
+	Hosts       []string               `json:"hosts"`
+	Tags        []string               `json:"tags"`
+	Annotations []documents.Annotation `json:"annotations"`
+	LowerLayers []OpaLowerLayerScore   `json:"lowerLayers"`
+}
+
+// OpaLowerLayerScore is a lower layer score found for a host or tag of the annotations. Stand-ins for missing scores
+// have no ScoreKey.
+type OpaLowerLayerScore struct {
+	Layer      contracts.LayerType `json:"layer"`
+	JoinOn     string              `json:"joinOn"`
+	Field      string              `json:"field"`
+	ScoreKey   string              `json:"scoreKey,omitempty"`
+	Confidence float64             `json:"confidence"`
+	Timestamp  time.Time           `json:"timestamp,omitempty"`
+}
+
+// SimulateRequest asks for the score a data item would receive under an alternate policy. The data is identified by
+// either the Id of its sample data record or its Key (dataRef). The weights come from either the inline Policy or the
+// policy provider's weights for Classifier. BasePolicy names the policy whose stored scores are used for the lower
+// layers and compared against; if empty, the most recent scores under any policy are used.
+type SimulateRequest struct {
+	Id         string              `json:"id,omitempty"`
+	Key        string              `json:"key,omitempty"`
+	Classifier string              `json:"classifier,omitempty"`
+	Policy     *policies.DcfPolicy `json:"policy,omitempty"`
+	BasePolicy string              `json:"basePolicy,omitempty"`
+}
@@ -17,16 +17,18 @@ package responses
 import (
 	"encoding/json"
 	"github.com/oklog/ulid/v2"
+	"github.com/project-alvarium/alvarium-sdk-go/pkg/contracts"
 	"github.com/project-alvarium/scoring-apps-go/pkg/documents"
+	"github.com/project-alvarium/scoring-apps-go/pkg/policies"
 )
 
 type OpaWeightsResponse struct {
-	Weights map[string]int `json:"result,omitempty"`
+	Weights OpaWeights `json:"result,omitempty"`
 }
 
 func (p *OpaWeightsResponse) UnmarshalJSON(data []byte) error {
 	type alias struct {
-		Result []map[string]int `json:"result,omitempty"`
+		Result []OpaWeights `json:"result,omitempty"`
 	}
 
 	a := alias{}
@@ -40,6 +42,52 @@ func (p *OpaWeightsResponse) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// OpaWeights maps annotation keys to their weights. Each weight is either a number or an object with the same fields
+// as a policy weight, such as {"value": 0.5, "required": true}.
+type OpaWeights map[string]policies.Weight
+
+func (w *OpaWeights) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	weights := make(OpaWeights, len(raw))
+	for k, v := range raw {
+		// Wrap plain numbers so they are bounded the same way as configured weights
+		if len(v) > 0 && v[0] != '{' {
+			v = []byte(`{"value":` + string(v) + `}`)
+		}
+		var weight policies.Weight
+		err = json.Unmarshal(v, &weight)
+		if err != nil {
+			return err
+		}
+		weight.AnnotationKey = k
+		weights[k] = weight
+	}
+	*w = weights
+	return nil
+}
+
+// OpaBandsResponse holds the confidence bands of a class
+type OpaBandsResponse struct {
+	Bands []policies.Band `json:"result,omitempty"`
+}
+
+// OpaDecisionResponse holds the outcome of a decision rule
+type OpaDecisionResponse struct {
+	Result OpaDecision `json:"result"`
+}
+
+// OpaDecision carries either the weights to score with or the confidence itself. If neither is set, the weights of the
+// policy are used.
+type OpaDecision struct {
+	Weights    OpaWeights `json:"weights,omitempty"`
+	Confidence *float64   `json:"confidence,omitempty"`
+}
+
 type AnnotationListResponse struct {
 	Count       int                    `json:"count"`
 	Annotations []documents.Annotation `json:"annotations"`
@@ -64,9 +112,53 @@ type SampleData struct {
 type DataViewModel struct {
 	SampleData
 	Confidence float64 `json:"confidence"`
+	Band       string  `json:"band,omitempty"`
 }
 
 type DataListResponse struct {
 	Count     int             `json:"count"`               // Count is the number of items in the list.
 	Documents []DataViewModel `json:"documents,omitempty"` // Documents is an array of the returned view models
 }
+
+// ScoreExplanationResponse explains how the current confidence score of a data item was calculated
+type ScoreExplanationResponse struct {
+	DataRef     string                 `json:"dataRef"`
+	ScoreKey    string                 `json:"scoreKey"`
+	Version     int                    `json:"version"`
+	Confidence  float64                `json:"confidence"`
+	Explanation *documents.Explanation `json:"explanation,omitempty"`
+}
+
+// SimulationResponse is the score a data item would receive under the requested policy. Nothing is persisted.
+type SimulationResponse struct {
+	DataRef           string                   `json:"dataRef"`
+	Layer             contracts.LayerType      `json:"layer,omitempty"`
+	Policy            string                   `json:"policy,omitempty"`
+	Passed            int                      `json:"score"`
+	Count             int                      `json:"count"`
+	Confidence        float64                  `json:"confidence"`
+	Band              string                   `json:"band,omitempty"`
+	CurrentConfidence *float64                 `json:"currentConfidence,omitempty"` // CurrentConfidence is the confidence of the stored score, if there is one
+	Explanation       *documents.Explanation   `json:"explanation,omitempty"`
+	Missing           []documents.MissingScore `json:"missing,omitempty"`
+	// Omitted names the steps of the calculator that the simulation does not apply, so that the simulated confidence
+	// may differ from the one the calculator stores
+	Omitted []string `json:"omitted,omitempty"`
+}
+
+// PolicyDiffResponse lists the weights that differ between two versions of a policy
+type PolicyDiffResponse struct {
+	Name    string            `json:"classifier"`
+	From    string            `json:"from"` // From is the key of the earlier version
+	To      string            `json:"to"`   // To is the key of the later version
+	Added   []policies.Weight `json:"added,omitempty"`
+	Removed []policies.Weight `json:"removed,omitempty"`
+	Changed []WeightChange    `json:"changed,omitempty"`
+}
+
+// WeightChange describes an annotation whose weight differs between two versions of a policy
+type WeightChange struct {
+	AnnotationKey string          `json:"key"`
+	From          policies.Weight `json:"from"`
+	To            policies.Weight `json:"to"`
+}
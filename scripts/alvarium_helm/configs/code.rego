@@ -14,3 +14,18 @@ weights[w] {
     not has_key(classes,class)
     w:=classes["default"]
 }
+
+# decision scores a piece of data in the context of its annotations. Data on a host whose OS score is below 0.2 is
+# given no confidence at all, otherwise the weights of its class are used.
+decision = {"confidence": 0} {
+    some i
+    input.lowerLayers[i].layer == "os"
+    input.lowerLayers[i].confidence < 0.2
+} else = {"weights": classes[class]} {
+    has_key(classes, class)
+} else = {"weights": classes["default"]}
+
+# bands label the confidence of a score. Production data needs a higher confidence to be trusted.
+bands = [{"name": "trusted", "min": 0.9}, {"name": "suspect", "min": 0.6}, {"name": "untrusted", "min": 0}] {
+    class == "production"
+} else = [{"name": "trusted", "min": 0.8}, {"name": "suspect", "min": 0.5}, {"name": "untrusted", "min": 0}]